@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+// binding is the requirement that a claim of the token matches the name the
+// token was fetched from, or the device ID in the name.
+type binding struct {
+	// claim is the name of the claim.
+	claim string
+
+	// deviceID binds the claim to the device ID instead of the FQDN.
+	deviceID bool
+}
+
+// check returns an error if the claim of the token doesn't match the name.
+// The name is matched using the template and base domains of the Fetcher.
+func (b *binding) check(r *Fetcher, name string, token jwt.Token) error {
+	// The claim is compared the same way, so a trailing dot or a change of
+	// case in the configured name doesn't matter.
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+
+	want, what := name, "fqdn"
+	if b.deviceID {
+		id, ok := deviceIDFrom(r.template, r.bases, name)
+		if !ok {
+			return fmt.Errorf("%w: %w: the name %q has no device id", ErrInvalidJWT, ErrNameMismatch, name)
+		}
+		want, what = id, "device id"
+	}
+
+	value, ok := token.Get(b.claim)
+	if !ok {
+		return fmt.Errorf("%w: %w: the %q claim is missing", ErrInvalidJWT, ErrNameMismatch, b.claim)
+	}
+
+	values := claimStrings(value)
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSuffix(v, "."), want) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %w: the %q claim %q doesn't match the %s %q",
+		ErrInvalidJWT, ErrNameMismatch, b.claim, values, what, want)
+}
+
+// claimStrings returns the string values of a claim, which may be a string or
+// a list of strings like the aud claim.
+func claimStrings(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+
+	return nil
+}
+
+// deviceIDFrom extracts the device ID from the name using the name template.
+// If base domains are set, the name must be under one of them.
+func deviceIDFrom(tmpl string, bases []string, name string) (string, bool) {
+	base := `.+`
+	if len(bases) > 0 {
+		quoted := make([]string, 0, len(bases))
+		for _, b := range bases {
+			quoted = append(quoted, regexp.QuoteMeta(b))
+		}
+		base = strings.Join(quoted, "|")
+	}
+
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for i, part := range strings.Split(tmpl, DeviceIDPlaceholder) {
+		if i > 0 {
+			pattern.WriteString(`([^.]+)`)
+		}
+		pieces := strings.Split(strings.ToLower(part), BaseDomainPlaceholder)
+		for j, piece := range pieces {
+			if j > 0 {
+				pattern.WriteString("(?:" + base + ")")
+			}
+			pattern.WriteString(regexp.QuoteMeta(piece))
+		}
+	}
+	pattern.WriteString("$")
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return "", false
+	}
+
+	m := re.FindStringSubmatch(name)
+	if m == nil {
+		return "", false
+	}
+
+	// Each placeholder in the template must be the same device ID.
+	for _, id := range m[2:] {
+		if id != m[1] {
+			return "", false
+		}
+	}
+
+	return m[1], true
+}
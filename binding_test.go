@@ -0,0 +1,250 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinding(t *testing.T) {
+	tests := []struct {
+		name     string
+		claims   map[string]any
+		opts     []FetcherOption
+		fqdn     string
+		deviceID string
+		wantErr  bool
+	}{
+		{
+			name:   "fqdn in sub",
+			claims: map[string]any{"sub": "device-a.example.org"},
+			opts:   []FetcherOption{WithFQDNBinding("sub")},
+			fqdn:   "device-a.example.org",
+		}, {
+			name:    "copied from another name",
+			claims:  map[string]any{"sub": "device-a.example.org"},
+			opts:    []FetcherOption{WithFQDNBinding("sub")},
+			fqdn:    "device-b.example.org",
+			wantErr: true,
+		}, {
+			name:   "fqdn in aud, with case and trailing dot",
+			claims: map[string]any{"aud": []string{"other.example.org", "Device-A.Example.org."}},
+			opts:   []FetcherOption{WithFQDNBinding("aud")},
+			fqdn:   "device-a.example.org",
+		}, {
+			name:   "configured fqdn with case and trailing dot",
+			claims: map[string]any{"sub": "a.example.org"},
+			opts:   []FetcherOption{WithFQDNBinding("sub")},
+			fqdn:   "A.example.org.",
+		}, {
+			name:   "device id from a fqdn with case and trailing dot",
+			claims: map[string]any{"sub": "device-a"},
+			opts:   []FetcherOption{WithDeviceIDBinding("sub")},
+			fqdn:   "Device-A.example.org.",
+		}, {
+			name:   "custom claim",
+			claims: map[string]any{"fqdn": "device-a.example.org"},
+			opts:   []FetcherOption{WithFQDNBinding("fqdn")},
+			fqdn:   "device-a.example.org",
+		}, {
+			name:    "claim missing",
+			claims:  map[string]any{"sub": "device-a.example.org"},
+			opts:    []FetcherOption{WithFQDNBinding("fqdn")},
+			fqdn:    "device-a.example.org",
+			wantErr: true,
+		}, {
+			name:    "claim not a string",
+			claims:  map[string]any{"fqdn": 42},
+			opts:    []FetcherOption{WithFQDNBinding("fqdn")},
+			fqdn:    "device-a.example.org",
+			wantErr: true,
+		}, {
+			name:   "device id from the default template",
+			claims: map[string]any{"sub": "device-a"},
+			opts:   []FetcherOption{WithDeviceIDBinding("sub")},
+			fqdn:   "device-a.example.org",
+		}, {
+			name:    "device id mismatch",
+			claims:  map[string]any{"sub": "device-a"},
+			opts:    []FetcherOption{WithDeviceIDBinding("sub")},
+			fqdn:    "device-b.example.org",
+			wantErr: true,
+		}, {
+			name:   "device id from FetchFor",
+			claims: map[string]any{"sub": "device-a"},
+			opts: []FetcherOption{
+				WithDeviceIDBinding("sub"),
+				WithNameTemplate("{device_id}._jwt.{base}"),
+				WithBaseDomains("example.org", "example.net"),
+			},
+			deviceID: "device-a",
+		}, {
+			name:   "device id mismatch from FetchFor",
+			claims: map[string]any{"sub": "device-a"},
+			opts: []FetcherOption{
+				WithDeviceIDBinding("sub"),
+				WithNameTemplate("{device_id}._jwt.{base}"),
+				WithBaseDomains("example.org"),
+			},
+			deviceID: "device-b",
+			wantErr:  true,
+		}, {
+			name:   "name without a device id",
+			claims: map[string]any{"sub": "device-a"},
+			opts: []FetcherOption{
+				WithDeviceIDBinding("sub"),
+				WithNameTemplate("{device_id}._jwt.{base}"),
+			},
+			fqdn:    "device-a.example.org",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := MakePublicKeySet("fqdn.example.org", tt.claims)
+			require.NoError(t, err)
+
+			fqdn := tt.fqdn
+			if fqdn == "" {
+				fqdn = "unused.example.org"
+			}
+
+			opts := []FetcherOption{
+				WithFQDN(fqdn),
+				WithResolver(resolverFunc(func(ctx context.Context, _ string) ([]string, error) {
+					return set.resolver.LookupTXT(ctx, set.fqdn)
+				})),
+				WithParseOptions(set.provider),
+			}
+			fetcher, err := New(append(opts, tt.opts...)...)
+			require.NoError(t, err)
+
+			if tt.deviceID != "" {
+				_, _, err = fetcher.FetchFor(context.Background(), tt.deviceID)
+			} else {
+				_, _, err = fetcher.Fetch(context.Background())
+			}
+
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, ErrNameMismatch)
+			assert.ErrorIs(t, err, ErrInvalidJWT)
+
+			var fe *FetchError
+			require.ErrorAs(t, err, &fe)
+			assert.Equal(t, StageVerify, fe.Stage)
+
+			// The binding isn't checked without a name.
+			lines, err := set.resolver.LookupTXT(context.Background(), set.fqdn)
+			require.NoError(t, err)
+			_, _, err = fetcher.VerifyLines(context.Background(), lines)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestBindingRacingResolvers(t *testing.T) {
+	records, provider := rotatingRecords(t,
+		map[string]any{"sub": "device-a.example.org"},
+		map[string]any{"sub": "device-b.example.org"},
+	)
+
+	// The fast resolver serves a valid token for another name.
+	fast := resolverFunc(func(context.Context, string) ([]string, error) {
+		return records[0], nil
+	})
+	slow := resolverFunc(func(context.Context, string) ([]string, error) {
+		time.Sleep(20 * time.Millisecond)
+		return records[1], nil
+	})
+
+	fetcher, err := New(
+		WithFQDN("device-b.example.org"),
+		WithRacingResolvers(time.Millisecond, fast, slow),
+		WithParseOptions(provider),
+		WithFQDNBinding("sub"),
+	)
+	require.NoError(t, err)
+
+	token, _, err := fetcher.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "device-b.example.org", token.Subject())
+}
+
+func TestBindingInvalid(t *testing.T) {
+	_, err := New(WithFQDN("fqdn.example.org"), WithFQDNBinding(""))
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	_, err = New(WithFQDN("fqdn.example.org"), WithDeviceIDBinding(""))
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestDeviceIDFrom(t *testing.T) {
+	tests := []struct {
+		name   string
+		tmpl   string
+		bases  []string
+		fqdn   string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "default template",
+			tmpl:   DefaultNameTemplate,
+			fqdn:   "device.example.org",
+			want:   "device",
+			wantOK: true,
+		}, {
+			name:   "service label",
+			tmpl:   "{device_id}._jwt.{base}",
+			bases:  []string{"example.org"},
+			fqdn:   "device._jwt.example.org",
+			want:   "device",
+			wantOK: true,
+		}, {
+			name:  "other base",
+			tmpl:  "{device_id}._jwt.{base}",
+			bases: []string{"example.org"},
+			fqdn:  "device._jwt.example.net",
+		}, {
+			name:  "missing label",
+			tmpl:  "{device_id}._jwt.{base}",
+			fqdn:  "device.example.org",
+			bases: nil,
+		}, {
+			name:   "placeholder repeated",
+			tmpl:   "{device_id}.{device_id}.{base}",
+			fqdn:   "device.device.example.org",
+			want:   "device",
+			wantOK: true,
+		}, {
+			name: "placeholder repeated, different",
+			tmpl: "{device_id}.{device_id}.{base}",
+			fqdn: "device.other.example.org",
+		}, {
+			name:   "upper case template",
+			tmpl:   "{device_id}._JWT.Example.org",
+			fqdn:   "device._jwt.example.org",
+			want:   "device",
+			wantOK: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := deviceIDFrom(tt.tmpl, tt.bases, tt.fqdn)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+// cache holds the last verified token for each FQDN so repeated calls to
+// Fetch don't need to query DNS and verify the JWT each time.
+type cache struct {
+	// maxAge is the longest an entry is served.  Zero or less means the entry
+	// is only bounded by the token's exp claim.
+	maxAge time.Duration
+
+	// ttl bounds the entries by the TTL of the record.  The stale store
+	// doesn't use it, as its entries are meant to outlive the record.
+	ttl bool
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	res     *result
+	expires time.Time
+}
+
+func newCache(maxAge time.Duration, ttl bool) *cache {
+	return &cache{
+		maxAge:  maxAge,
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// get returns a copy of the cached result if the entry is present, has not
+// aged out and still passes the time based claim validation.  Entries that
+// fail are removed.
+func (c *cache) get(key string, now time.Time, opts []jwt.ValidateOption) (*result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, found := c.entries[key]
+	if !found {
+		return nil, false
+	}
+
+	if !entry.expires.IsZero() && !now.Before(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	// Re-validate the claims on every hit so a token is never served after it
+	// expires or before it becomes valid.
+	if err := jwt.Validate(entry.res.token, opts...); err != nil {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	res, err := entry.res.clone()
+	if err != nil {
+		return nil, false
+	}
+
+	return res, true
+}
+
+// put stores a copy of the result, computing when the entry should expire
+// based on the max age, the TTL of the record if known and used, and the
+// token's exp claim, whichever is earliest.
+func (c *cache) put(key string, res *result, now time.Time) {
+	var expires time.Time
+	if c.maxAge > 0 {
+		expires = now.Add(c.maxAge)
+	}
+
+	if c.ttl && res.ttl > 0 {
+		if ttl := now.Add(res.ttl); expires.IsZero() || ttl.Before(expires) {
+			expires = ttl
+		}
+	}
+
+	if exp := res.token.Expiration(); !exp.IsZero() {
+		if expires.IsZero() || exp.Before(expires) {
+			expires = exp
+		}
+	}
+
+	clone, err := res.clone()
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = cacheEntry{
+		res:     clone,
+		expires: expires,
+	}
+}
@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchWithCache(t *testing.T) {
+	now := time.Now()
+
+	a, err := MakeTrustedSet("fqdn.example.org", map[string]any{
+		"example": "A",
+		"exp":     now.Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		maxAge      time.Duration
+		advance     time.Duration
+		wantLookups int64
+	}{
+		{
+			name:        "served from the cache",
+			maxAge:      time.Minute,
+			wantLookups: 1,
+		}, {
+			name:        "max age exceeded",
+			maxAge:      time.Minute,
+			advance:     2 * time.Minute,
+			wantLookups: 2,
+		}, {
+			name:        "no max age, bounded by exp",
+			advance:     30 * time.Minute,
+			wantLookups: 1,
+		}, {
+			name:        "token expired",
+			maxAge:      2 * time.Hour,
+			advance:     90 * time.Minute,
+			wantLookups: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lookups atomic.Int64
+			counter := resolverFunc(func(ctx context.Context, name string) ([]string, error) {
+				lookups.Add(1)
+				return a.resolver.LookupTXT(ctx, name)
+			})
+
+			clock := now
+			fetcher, err := New(
+				WithFQDN(a.fqdn),
+				WithResolver(counter),
+				WithParseOptions(a.provider),
+				WithCache(tt.maxAge),
+			)
+			require.NoError(t, err)
+			fetcher.clock = jwt.ClockFunc(func() time.Time { return clock })
+
+			ctx := context.Background()
+			token, buf, err := fetcher.Fetch(ctx)
+			require.NoError(t, err)
+			require.NotNil(t, token)
+			assert.Equal(t, a.payload, buf)
+
+			clock = clock.Add(tt.advance)
+			token, buf, err = fetcher.Fetch(ctx)
+			require.NoError(t, err)
+			require.NotNil(t, token)
+			assert.Equal(t, a.payload, buf)
+
+			assert.Equal(t, tt.wantLookups, lookups.Load())
+		})
+	}
+}
+
+func TestCacheRevalidates(t *testing.T) {
+	now := time.Now()
+
+	token := jwt.New()
+	require.NoError(t, token.Set(jwt.ExpirationKey, now.Add(time.Hour)))
+
+	c := newCache(0, true)
+	c.put("name", &result{token: token, payload: []byte("payload")}, now)
+
+	got, ok := c.get("name", now, nil)
+	require.True(t, ok)
+	require.NotNil(t, got)
+	assert.Equal(t, []byte("payload"), got.payload)
+
+	// The validation clock is ahead of the cache clock, so the entry must
+	// still be rejected.
+	later := jwt.WithClock(jwt.ClockFunc(func() time.Time { return now.Add(2 * time.Hour) }))
+	got, ok = c.get("name", now, []jwt.ValidateOption{later})
+	assert.False(t, ok)
+	assert.Nil(t, got)
+
+	// The failed entry is removed.
+	_, ok = c.get("name", now, nil)
+	assert.False(t, ok)
+}
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"sync"
+)
+
+// group coalesces concurrent loads of the same name so that all callers share
+// one lookup and one verification.
+type group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// call is a load that is in flight.
+type call struct {
+	done    chan struct{}
+	res     *result
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// do calls fn once for all concurrent callers with the same key and returns
+// the shared result.  The shared call is not bound to any one caller's
+// context; each caller stops waiting when its own context is done, and the
+// shared call is canceled once no callers are left waiting for it.
+func (g *group) do(ctx context.Context, key string, fn func(context.Context) (*result, error)) (*result, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+
+	c, found := g.calls[key]
+	if !found {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		c = &call{
+			done:   make(chan struct{}),
+			cancel: cancel,
+		}
+		g.calls[key] = c
+
+		go func() {
+			c.res, c.err = fn(callCtx)
+			cancel()
+
+			g.mu.Lock()
+			g.forget(key, c)
+			g.mu.Unlock()
+
+			close(c.done)
+		}()
+	}
+	c.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.res, c.err
+	case <-ctx.Done():
+	}
+
+	g.mu.Lock()
+	c.waiters--
+	if c.waiters == 0 {
+		// Nobody is waiting anymore, so stop the work and make sure the next
+		// caller starts a new call.
+		c.cancel()
+		g.forget(key, c)
+	}
+	g.mu.Unlock()
+
+	return nil, ctx.Err()
+}
+
+// forget removes the call if it is still the one registered for the key.  The
+// caller must hold the lock.
+func (g *group) forget(key string, c *call) {
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+}
@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchCoalesces(t *testing.T) {
+	a, err := MakeTrustedSet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+
+	var lookups atomic.Int64
+	release := make(chan struct{})
+	resolver := resolverFunc(func(ctx context.Context, name string) ([]string, error) {
+		lookups.Add(1)
+		<-release
+		return a.resolver.LookupTXT(ctx, name)
+	})
+
+	fetcher, err := New(
+		WithFQDN(a.fqdn),
+		WithResolver(resolver),
+		WithParseOptions(a.provider),
+	)
+	require.NoError(t, err)
+
+	const callers = 50
+
+	var wg sync.WaitGroup
+	tokens := make([]jwt.Token, callers)
+	errs := make([]error, callers)
+
+	// One caller gives up early, which must not affect the others.
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		_, _, err := fetcher.Fetch(ctx)
+		canceled <- err
+	}()
+
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tokens[i], _, errs[i] = fetcher.Fetch(context.Background())
+		}()
+	}
+
+	// Wait for all of the callers to join the in flight call.
+	require.Eventually(t, func() bool {
+		return fetcher.inflight.waiting(a.fqdn) == callers+1
+	}, 5*time.Second, time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-canceled, context.Canceled)
+
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int64(1), lookups.Load())
+	for i := range callers {
+		require.NoError(t, errs[i])
+		assert.Same(t, tokens[0], tokens[i])
+	}
+}
+
+// waiting returns the number of callers waiting for the call in flight for
+// the key.
+func (g *group) waiting(key string) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if c, found := g.calls[key]; found {
+		return c.waiters
+	}
+	return 0
+}
+
+func TestGroupCancelsAbandonedCalls(t *testing.T) {
+	var g group
+
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	fn := func(ctx context.Context) (*result, error) {
+		close(started)
+		<-ctx.Done()
+		close(stopped)
+		return nil, ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	res, err := g.do(ctx, "name", fn)
+	assert.Nil(t, res)
+	require.ErrorIs(t, err, context.Canceled)
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		require.FailNow(t, "the abandoned call was not canceled")
+	}
+
+	// A new call is started once the abandoned one is forgotten.
+	res, err = g.do(context.Background(), "name", func(context.Context) (*result, error) {
+		return &result{raw: "new"}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "new", res.raw)
+}
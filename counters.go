@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"expvar"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Counters is an Observer that counts the events.  The zero value is ready to
+// use, and it is safe for concurrent use.
+type Counters struct {
+	lookups            atomic.Int64
+	lookupErrors       atomic.Int64
+	lookupNanos        atomic.Int64
+	maxLookupNanos     atomic.Int64
+	lines              atomic.Int64
+	bytes              atomic.Int64
+	reassemblyProblems atomic.Int64
+	verifications      atomic.Int64
+	verifyErrors       atomic.Int64
+}
+
+// Stats is a snapshot of the Counters.
+type Stats struct {
+	// Lookups is the number of TXT lookups made, including retries.
+	Lookups int64 `json:"lookups"`
+
+	// LookupErrors is the number of lookups that failed.
+	LookupErrors int64 `json:"lookup_errors"`
+
+	// LookupLatency is the total time spent in lookups.
+	LookupLatency time.Duration `json:"lookup_latency_ns"`
+
+	// MaxLookupLatency is the longest lookup.
+	MaxLookupLatency time.Duration `json:"max_lookup_latency_ns"`
+
+	// Lines is the total number of lines received.
+	Lines int64 `json:"lines"`
+
+	// Bytes is the total length of the lines received.
+	Bytes int64 `json:"bytes"`
+
+	// ReassemblyProblems is the number of problems found with the lines of
+	// the records.
+	ReassemblyProblems int64 `json:"reassembly_problems"`
+
+	// Verifications is the number of JWTs verified.
+	Verifications int64 `json:"verifications"`
+
+	// VerifyErrors is the number of JWTs that failed verification.
+	VerifyErrors int64 `json:"verify_errors"`
+}
+
+// OnLookupStart is part of the Observer interface.
+func (c *Counters) OnLookupStart(LookupStartEvent) {}
+
+// OnLookupDone is part of the Observer interface.
+func (c *Counters) OnLookupDone(e LookupDoneEvent) {
+	c.lookups.Add(1)
+	if e.Err != nil {
+		c.lookupErrors.Add(1)
+	}
+
+	nanos := int64(e.Latency)
+	c.lookupNanos.Add(nanos)
+	for {
+		prev := c.maxLookupNanos.Load()
+		if nanos <= prev || c.maxLookupNanos.CompareAndSwap(prev, nanos) {
+			break
+		}
+	}
+
+	c.lines.Add(int64(e.Lines))
+	c.bytes.Add(int64(e.Bytes))
+}
+
+// OnReassembly is part of the Observer interface.
+func (c *Counters) OnReassembly(e ReassemblyEvent) {
+	c.reassemblyProblems.Add(int64(len(e.Problems)))
+}
+
+// OnVerify is part of the Observer interface.
+func (c *Counters) OnVerify(e VerifyEvent) {
+	c.verifications.Add(1)
+	if e.Err != nil {
+		c.verifyErrors.Add(1)
+	}
+}
+
+// Stats returns a snapshot of the counters.
+func (c *Counters) Stats() Stats {
+	return Stats{
+		Lookups:            c.lookups.Load(),
+		LookupErrors:       c.lookupErrors.Load(),
+		LookupLatency:      time.Duration(c.lookupNanos.Load()),
+		MaxLookupLatency:   time.Duration(c.maxLookupNanos.Load()),
+		Lines:              c.lines.Load(),
+		Bytes:              c.bytes.Load(),
+		ReassemblyProblems: c.reassemblyProblems.Load(),
+		Verifications:      c.verifications.Load(),
+		VerifyErrors:       c.verifyErrors.Load(),
+	}
+}
+
+// Publish publishes the stats with the expvar package under the name.  It
+// fails if the name is already in use.
+func (c *Counters) Publish(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w expvar name must be set", ErrInvalidInput)
+	}
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("%w expvar name %q is already in use", ErrInvalidInput, name)
+	}
+
+	expvar.Publish(name, expvar.Func(func() any {
+		return c.Stats()
+	}))
+
+	return nil
+}
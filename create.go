@@ -4,12 +4,15 @@
 package dnstxtjwt
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 )
 
 type create struct {
 	maxSize       int
 	maxLineLength int
+	log           logConfig
 }
 
 func (c create) split(buf []byte) (result []string, n int) {
@@ -40,29 +43,45 @@ func (c create) split(buf []byte) (result []string, n int) {
 }
 
 type CreateOption interface {
-	apply(*create)
+	applyCreate(*create)
 }
 
+// CreateRecord splits the JWT into the lines of a TXT record.  ParseRecord
+// turns the lines back into the JWT.
 func CreateRecord(jwt string, opts ...CreateOption) ([]string, error) {
 	var c create
 
 	defaults := []CreateOption{ // nolint:prealloc
 		WithMaxSize(0),
 		WithMaxLineLength(0),
+		WithLogger(nil),
 	}
 
 	opts = append(defaults, opts...)
 
 	for _, opt := range opts {
 		if opt != nil {
-			opt.apply(&c)
+			opt.applyCreate(&c)
 		}
 	}
 
 	lines, n := c.split([]byte(jwt))
 	if n > c.maxSize {
+		c.log.logger.LogAttrs(context.Background(), slog.LevelDebug, "TXT record is too large",
+			slog.Int("size", n),
+			slog.Int("max_size", c.maxSize),
+			slog.Int("lines", len(lines)),
+			slog.Any("token", c.log.token(jwt)),
+		)
 		return nil, ErrInvalidInput
 	}
 
+	c.log.logger.LogAttrs(context.Background(), slog.LevelDebug, "created TXT record",
+		slog.Int("size", n),
+		slog.Int("lines", len(lines)),
+		slog.Int("max_line_length", c.maxLineLength),
+		slog.Any("token", c.log.token(jwt)),
+	)
+
 	return lines, nil
 }
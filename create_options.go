@@ -5,7 +5,7 @@ package dnstxtjwt
 
 type createOptionFunc func(*create)
 
-func (f createOptionFunc) apply(r *create) {
+func (f createOptionFunc) applyCreate(r *create) {
 	f(r)
 }
 
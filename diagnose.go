@@ -0,0 +1,208 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jws"
+)
+
+// FetchReport describes each step of fetching the JWT, to find out why a
+// fetch fails.  It is JSON serializable.
+//
+// The report holds the lines of the records and the claims of the tokens, so
+// treat it with the same care as the tokens.
+type FetchReport struct {
+	// FQDN is the name the token verified at, if any.
+	FQDN string `json:"fqdn,omitempty"`
+
+	// Names are the reports of the names tried, in order.
+	Names []NameReport `json:"names"`
+}
+
+// NameReport describes fetching the JWT from one name.
+type NameReport struct {
+	// FQDN is the name.
+	FQDN string `json:"fqdn"`
+
+	// Lines are the lines of the TXT record, in the order received.
+	Lines []LineReport `json:"lines,omitempty"`
+
+	// Gaps are the indexes found missing from the record.  Reassembly stops
+	// at the first one.
+	Gaps []int `json:"gaps,omitempty"`
+
+	// Duplicates are the indexes used by more than one line.
+	Duplicates []int `json:"duplicates,omitempty"`
+
+	// ReassembledLength is the length of the reassembled JWT.
+	ReassembledLength int `json:"reassembled_length"`
+
+	// Header is the decoded, unverified, protected header of the JWT.
+	Header map[string]any `json:"header,omitempty"`
+
+	// Claims are the decoded, unverified, claims of the JWT.
+	Claims map[string]any `json:"claims,omitempty"`
+
+	// Verified reports if the JWT verified.
+	Verified bool `json:"verified"`
+
+	// Stages are the stages performed, in order.
+	Stages []StageReport `json:"stages"`
+
+	// Error is the reason the fetch failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// LineReport describes a line of the TXT record.
+type LineReport struct {
+	// Raw is the line as received.
+	Raw string `json:"raw"`
+
+	// Index is the parsed index of the line, or -1 if it is malformed.
+	Index int `json:"index"`
+
+	// Rejected is the reason the line was rejected, if it was.  Without
+	// WithStrictReassembly, only malformed lines are rejected.
+	Rejected string `json:"rejected,omitempty"`
+}
+
+// StageReport describes the outcome of a stage.
+type StageReport struct {
+	// Stage is the stage.
+	Stage Stage `json:"stage"`
+
+	// Duration is how long the stage took.
+	Duration time.Duration `json:"duration_ns"`
+
+	// Error is the reason the stage failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// Diagnose fetches the JWT the same way FetchResult does, but without the
+// cache and stale store, and reports each step for each name tried.  An error
+// is only returned if the diagnosis can't be performed; the failure to fetch
+// the JWT is in the report.  The highest version accepted by WithAntiRollback
+// is checked, but never raised, and the observers set by WithObserver don't
+// receive the events of the diagnosis.
+func (r *Fetcher) Diagnose(ctx context.Context) (*FetchReport, error) {
+	if len(r.fqdns) == 0 {
+		return nil, fmt.Errorf("%w fqdn must be set", ErrInvalidInput)
+	}
+
+	return r.diagnoseNames(ctx, r.fqdns), nil
+}
+
+// DiagnoseFor is the same as Diagnose, but the names are built for the device
+// ID the same way FetchResultFor builds them.
+func (r *Fetcher) DiagnoseFor(ctx context.Context, deviceID string) (*FetchReport, error) {
+	names, err := r.namesFor(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.diagnoseNames(ctx, names), nil
+}
+
+// diagnoseNames reports the fetch from the first of the names that verifies.
+func (r *Fetcher) diagnoseNames(ctx context.Context, names []string) *FetchReport {
+	// A diagnosis isn't part of the traffic the observers measure.
+	quiet := *r
+	quiet.observer = nil
+
+	var report FetchReport
+
+	for _, name := range names {
+		nr := NameReport{FQDN: name}
+
+		_, err := quiet.refreshName(ctx, name, &nr)
+		if err == nil {
+			nr.Verified = true
+			report.FQDN = name
+		} else {
+			nr.Error = err.Error()
+		}
+		report.Names = append(report.Names, nr)
+
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+
+	return &report
+}
+
+// stage records the outcome of a stage that began at start.  It does nothing
+// if the report is nil.
+func (nr *NameReport) stage(stage Stage, start time.Time, err error) {
+	if nr == nil {
+		return
+	}
+
+	sr := StageReport{
+		Stage:    stage,
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		sr.Error = err.Error()
+	}
+
+	nr.Stages = append(nr.Stages, sr)
+}
+
+// describe records the lines of the record, the problems found reassembling
+// them and the decoded JWT.  It does nothing if the report is nil.
+func (nr *NameReport) describe(lines []string, txt string, problems []ReassemblyProblem, strict bool) {
+	if nr == nil {
+		return
+	}
+
+	for _, line := range lines {
+		n, _, _ := parseLine(line)
+		nr.Lines = append(nr.Lines, LineReport{Raw: line, Index: n})
+	}
+
+	for _, p := range problems {
+		switch p.Kind {
+		case MissingChunk:
+			nr.Gaps = append(nr.Gaps, p.Index)
+		case DuplicateChunk:
+			if !slices.Contains(nr.Duplicates, p.Index) {
+				nr.Duplicates = append(nr.Duplicates, p.Index)
+			}
+		}
+
+		// Any problem rejects the line with strict reassembly.
+		if p.Line >= 0 && (strict || p.Kind == MalformedLine) && nr.Lines[p.Line].Rejected == "" {
+			nr.Lines[p.Line].Rejected = p.Reason
+		}
+	}
+	slices.Sort(nr.Duplicates)
+
+	nr.ReassembledLength = len(txt)
+	if txt == "" {
+		return
+	}
+
+	msg, err := jws.Parse([]byte(txt))
+	if err != nil {
+		return
+	}
+
+	if sigs := msg.Signatures(); len(sigs) > 0 {
+		if header, err := sigs[0].ProtectedHeaders().AsMap(context.Background()); err == nil {
+			nr.Header = header
+		}
+	}
+
+	var claims map[string]any
+	if err := json.Unmarshal(msg.Payload(), &claims); err == nil {
+		nr.Claims = claims
+	}
+}
@@ -0,0 +1,275 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnose(t *testing.T) {
+	a, err := MakePublicKeySet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+	other, err := MakePublicKeySet("fqdn.example.org", map[string]any{"example": "B"})
+	require.NoError(t, err)
+
+	record, err := CreateRecord(string(a.jwt), WithMaxLineLength(50))
+	require.NoError(t, err)
+	require.Greater(t, len(record), 2)
+
+	// A malformed line, a duplicated line and a missing line.
+	broken := []string{"malformed", record[0], record[0]}
+	broken = append(broken, record[2:]...)
+
+	nxdomain := &net.DNSError{Err: "no such host", IsNotFound: true}
+
+	tests := []struct {
+		name           string
+		lines          []string
+		err            error
+		provider       Set
+		strict         bool
+		wantVerified   bool
+		wantStages     []Stage
+		wantFailed     Stage
+		wantLines      []LineReport
+		wantGaps       []int
+		wantDuplicates []int
+		wantLength     int
+		wantClaims     bool
+	}{
+		{
+			name:         "verified",
+			lines:        record,
+			provider:     a,
+			wantVerified: true,
+			wantStages:   []Stage{StageLookup, StageReassemble, StageVerify},
+			wantLength:   len(a.jwt),
+			wantClaims:   true,
+		}, {
+			name:       "lookup failure",
+			err:        nxdomain,
+			provider:   a,
+			wantStages: []Stage{StageLookup},
+			wantFailed: StageLookup,
+		}, {
+			name:       "broken record",
+			lines:      broken,
+			provider:   a,
+			wantStages: []Stage{StageLookup, StageReassemble},
+			wantFailed: StageReassemble,
+			wantLines: []LineReport{
+				{Raw: "malformed", Index: -1, Rejected: "missing ':' separator"},
+				{Raw: record[0], Index: 0},
+				{Raw: record[0], Index: 0},
+			},
+			wantGaps:       []int{1},
+			wantDuplicates: []int{0},
+		}, {
+			name:       "not starting at 0, strict",
+			lines:      record[1:],
+			provider:   a,
+			strict:     true,
+			wantStages: []Stage{StageLookup, StageReassemble},
+			wantFailed: StageReassemble,
+			wantGaps:   []int{0},
+		}, {
+			name:       "duplicate line, strict",
+			lines:      append([]string{record[0]}, record...),
+			provider:   a,
+			strict:     true,
+			wantStages: []Stage{StageLookup, StageReassemble},
+			wantFailed: StageReassemble,
+			wantLines: []LineReport{
+				{Raw: record[0], Index: 0},
+				{Raw: record[0], Index: 0, Rejected: "has the same index as line 0"},
+				{Raw: record[1], Index: 1},
+			},
+			wantDuplicates: []int{0},
+		}, {
+			name:       "bad signature",
+			lines:      record,
+			provider:   other,
+			wantStages: []Stage{StageLookup, StageReassemble, StageVerify},
+			wantFailed: StageVerify,
+			wantLength: len(a.jwt),
+			wantClaims: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []FetcherOption{
+				WithFQDN(a.fqdn),
+				WithResolver(resolverFunc(func(context.Context, string) ([]string, error) {
+					return tt.lines, tt.err
+				})),
+				WithParseOptions(tt.provider.provider),
+			}
+			if tt.strict {
+				opts = append(opts, WithStrictReassembly())
+			}
+			fetcher, err := New(opts...)
+			require.NoError(t, err)
+
+			report, err := fetcher.Diagnose(context.Background())
+			require.NoError(t, err)
+			require.Len(t, report.Names, 1)
+
+			nr := report.Names[0]
+			assert.Equal(t, a.fqdn, nr.FQDN)
+			assert.Equal(t, tt.wantVerified, nr.Verified)
+			assert.Equal(t, tt.wantVerified, report.FQDN == a.fqdn)
+			assert.Equal(t, tt.wantVerified, nr.Error == "")
+
+			stages := make([]Stage, 0, len(nr.Stages))
+			for _, sr := range nr.Stages {
+				stages = append(stages, sr.Stage)
+				assert.Equal(t, sr.Stage == tt.wantFailed, sr.Error != "", sr.Stage)
+			}
+			assert.Equal(t, tt.wantStages, stages)
+
+			if tt.wantLines != nil {
+				assert.Equal(t, tt.wantLines, nr.Lines[:len(tt.wantLines)])
+			}
+			assert.Equal(t, len(tt.lines), len(nr.Lines))
+			assert.Equal(t, tt.wantGaps, nr.Gaps)
+			assert.Equal(t, tt.wantDuplicates, nr.Duplicates)
+			assert.Equal(t, tt.wantLength, nr.ReassembledLength)
+
+			if tt.wantClaims {
+				assert.Equal(t, "A", nr.Claims["example"])
+				assert.NotEmpty(t, nr.Header["alg"])
+			} else {
+				assert.Nil(t, nr.Claims)
+				assert.Nil(t, nr.Header)
+			}
+
+			buf, err := json.Marshal(report)
+			require.NoError(t, err)
+
+			var decoded FetchReport
+			require.NoError(t, json.Unmarshal(buf, &decoded))
+			assert.Equal(t, report.FQDN, decoded.FQDN)
+			assert.Equal(t, len(report.Names[0].Stages), len(decoded.Names[0].Stages))
+		})
+	}
+}
+
+func TestDiagnoseFallback(t *testing.T) {
+	a, err := MakePublicKeySet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+
+	record, err := a.resolver.LookupTXT(context.Background(), a.fqdn)
+	require.NoError(t, err)
+
+	var calls atomic.Int64
+	fetcher, err := New(
+		WithFQDNs("first.example.org", "second.example.org", "third.example.org"),
+		WithResolver(resolverFunc(func(_ context.Context, name string) ([]string, error) {
+			calls.Add(1)
+			if name == "first.example.org" {
+				return nil, &net.DNSError{Err: "no such host", IsNotFound: true}
+			}
+			return record, nil
+		})),
+		WithParseOptions(a.provider),
+	)
+	require.NoError(t, err)
+
+	_, _, err = fetcher.Fetch(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(2), calls.Load())
+
+	// The cache is bypassed, and the names after the one that verifies are
+	// not tried.
+	report, err := fetcher.Diagnose(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), calls.Load())
+	assert.Equal(t, "second.example.org", report.FQDN)
+
+	require.Len(t, report.Names, 2)
+	assert.Equal(t, "first.example.org", report.Names[0].FQDN)
+	assert.False(t, report.Names[0].Verified)
+	assert.Contains(t, report.Names[0].Error, "not found")
+	assert.Equal(t, "second.example.org", report.Names[1].FQDN)
+	assert.True(t, report.Names[1].Verified)
+}
+
+func TestDiagnoseObservers(t *testing.T) {
+	a, err := MakePublicKeySet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+
+	var counters Counters
+	fetcher, err := New(
+		WithFQDN(a.fqdn),
+		WithResolver(a.resolver),
+		WithParseOptions(a.provider),
+		WithObserver(&counters),
+	)
+	require.NoError(t, err)
+
+	report, err := fetcher.Diagnose(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, a.fqdn, report.FQDN)
+	assert.Equal(t, Stats{}, counters.Stats())
+
+	_, _, err = fetcher.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), counters.Stats().Lookups)
+	assert.Equal(t, int64(1), counters.Stats().Verifications)
+}
+
+func TestDiagnoseFor(t *testing.T) {
+	a, err := MakePublicKeySet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+
+	record, err := a.resolver.LookupTXT(context.Background(), a.fqdn)
+	require.NoError(t, err)
+
+	fetcher, err := New(
+		WithNameTemplate("{device_id}._jwt.{base}"),
+		WithBaseDomains("example.org", "example.net"),
+		WithResolver(resolverFunc(func(_ context.Context, name string) ([]string, error) {
+			if name == "device-a._jwt.example.net" {
+				return record, nil
+			}
+			return nil, &net.DNSError{Err: "no such host", IsNotFound: true}
+		})),
+		WithParseOptions(a.provider),
+	)
+	require.NoError(t, err)
+
+	report, err := fetcher.DiagnoseFor(context.Background(), "device-a")
+	require.NoError(t, err)
+	assert.Equal(t, "device-a._jwt.example.net", report.FQDN)
+
+	require.Len(t, report.Names, 2)
+	assert.Equal(t, "device-a._jwt.example.org", report.Names[0].FQDN)
+	assert.False(t, report.Names[0].Verified)
+	assert.Equal(t, "device-a._jwt.example.net", report.Names[1].FQDN)
+	assert.True(t, report.Names[1].Verified)
+
+	report, err = fetcher.DiagnoseFor(context.Background(), "")
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	assert.Nil(t, report)
+}
+
+func TestDiagnoseInvalid(t *testing.T) {
+	var fetcher Fetcher
+
+	report, err := fetcher.Diagnose(context.Background())
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	assert.Nil(t, report)
+
+	report, err = fetcher.DiagnoseFor(context.Background(), "device-a")
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	assert.Nil(t, report)
+}
@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// defaultUDPSize is the EDNS0 buffer size advertised in UDP queries.
+const defaultUDPSize = 1232
+
+// DNSResolver is a TXTRecordResolver that sends queries directly to the
+// configured nameservers, exposing the TTL, response code and authenticated
+// data bit of the response.
+type DNSResolver struct {
+	servers    []string
+	timeout    time.Duration
+	udpSize    uint16
+	roundRobin bool
+
+	// next is the index of the nameserver to start with when round-robin
+	// selection is used.
+	next atomic.Uint64
+}
+
+// DNSOption is the interface that all DNSResolver options must implement.
+type DNSOption interface {
+	apply(*DNSResolver) error
+}
+
+// NewDNSResolver creates a new DNSResolver that queries the nameservers, in
+// order, until one answers.  A nameserver is an address like "10.0.0.1:53";
+// if the port is missing, port 53 is used.
+//
+// Queries are sent over UDP, and sent again over TCP if the response is
+// truncated.
+func NewDNSResolver(servers []string, opts ...DNSOption) (*DNSResolver, error) {
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("%w at least one nameserver must be set", ErrInvalidInput)
+	}
+
+	d := DNSResolver{
+		servers: make([]string, 0, len(servers)),
+	}
+
+	for _, server := range servers {
+		if server == "" {
+			return nil, fmt.Errorf("%w nameserver must be set", ErrInvalidInput)
+		}
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+		}
+		d.servers = append(d.servers, server)
+	}
+
+	defaults := []DNSOption{ // nolint:prealloc
+		WithQueryTimeout(0),
+		WithUDPSize(0),
+	}
+
+	opts = append(defaults, opts...)
+
+	for _, opt := range opts {
+		if opt != nil {
+			if err := opt.apply(&d); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &d, nil
+}
+
+// LookupTXT returns the DNS TXT records for the given domain name.
+func (d *DNSResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	answer, err := d.LookupTXTRecord(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return answer.Lines, nil
+}
+
+// LookupTXTRecord returns the DNS TXT records for the given domain name along
+// with the details of the response.
+func (d *DNSResolver) LookupTXTRecord(ctx context.Context, name string) (*TXTResult, error) {
+	return lookupTXTRecord(ctx, name, strings.Join(d.servers, ","), d.udpSize, d.Exchange)
+}
+
+// Exchange sends the query to each of the nameservers in order until one
+// answers.  A nameserver that fails or answers with SERVFAIL or REFUSED is
+// skipped.  With round-robin selection, each query starts with the next
+// nameserver.
+func (d *DNSResolver) Exchange(ctx context.Context, query *dns.Msg) (*dns.Msg, error) {
+	var errs []error
+	var last *dns.Msg
+
+	for _, server := range d.order() {
+		msg, err := d.exchange(ctx, server, query)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", server, err))
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+
+		switch msg.Rcode {
+		case dns.RcodeServerFailure, dns.RcodeRefused:
+			last = msg
+			continue
+		}
+
+		return msg, nil
+	}
+
+	// Report the failed answer if there was one, since the rcode is more
+	// useful than the transport errors.
+	if last != nil {
+		return last, nil
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// order returns the nameservers in the order to try them.
+func (d *DNSResolver) order() []string {
+	if !d.roundRobin || len(d.servers) < 2 {
+		return d.servers
+	}
+
+	start := int((d.next.Add(1) - 1) % uint64(len(d.servers)))
+
+	servers := make([]string, 0, len(d.servers))
+	servers = append(servers, d.servers[start:]...)
+	return append(servers, d.servers[:start]...)
+}
+
+// exchange sends the query to a single nameserver over UDP, and again over
+// TCP if the response is truncated.
+func (d *DNSResolver) exchange(ctx context.Context, server string, query *dns.Msg) (*dns.Msg, error) {
+	client := dns.Client{
+		Net:     "udp",
+		Timeout: d.timeout,
+		UDPSize: d.udpSize,
+	}
+
+	msg, _, err := client.ExchangeContext(ctx, query, server)
+	if err != nil || !msg.Truncated {
+		return msg, err
+	}
+
+	client.Net = "tcp"
+	msg, _, err = client.ExchangeContext(ctx, query, server)
+
+	return msg, err
+}
@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+type dnsOptionFunc func(*DNSResolver) error
+
+func (f dnsOptionFunc) apply(d *DNSResolver) error {
+	return f(d)
+}
+
+// WithQueryTimeout sets the timeout for a query to a single nameserver.  Any
+// value of zero or less sets the default of 5s.
+func WithQueryTimeout(timeout time.Duration) DNSOption {
+	return dnsOptionFunc(
+		func(d *DNSResolver) error {
+			if timeout <= 0 {
+				timeout = 5 * time.Second
+			}
+			d.timeout = timeout
+			return nil
+		},
+	)
+}
+
+// WithUDPSize sets the EDNS0 buffer size advertised in UDP queries, which is
+// the largest UDP response the nameservers may send.  Larger responses are
+// truncated and the query is sent again over TCP.  A value of zero sets the
+// default of 1232 bytes; values below 512 are invalid.
+func WithUDPSize(size uint16) DNSOption {
+	return dnsOptionFunc(
+		func(d *DNSResolver) error {
+			if size == 0 {
+				size = defaultUDPSize
+			}
+			if size < dns.MinMsgSize {
+				return fmt.Errorf("%w UDP size must be at least %d", ErrInvalidInput, dns.MinMsgSize)
+			}
+			d.udpSize = size
+			return nil
+		},
+	)
+}
+
+// WithRoundRobin spreads the queries across the nameservers, starting each
+// query with the next nameserver in the list.  A nameserver that fails is
+// still skipped in favor of the following ones.  By default, the nameservers
+// are always tried in order.
+func WithRoundRobin() DNSOption {
+	return dnsOptionFunc(
+		func(d *DNSResolver) error {
+			d.roundRobin = true
+			return nil
+		},
+	)
+}
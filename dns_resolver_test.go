@@ -0,0 +1,374 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startDNSServer starts a DNS server answering from the zone over UDP and TCP
+// on the same port, and returns its address along with a count of the queries
+// it received.  UDP responses larger than the advertised buffer size are
+// truncated.
+func startDNSServer(t *testing.T, zone testZone) (string, *atomic.Int64) {
+	t.Helper()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ln, err := net.Listen("tcp", pc.LocalAddr().String())
+	require.NoError(t, err)
+
+	var queries atomic.Int64
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, query *dns.Msg) {
+		queries.Add(1)
+
+		resp := zone.answer(query)
+		if w.LocalAddr().Network() == "udp" {
+			size := dns.MinMsgSize
+			if opt := query.IsEdns0(); opt != nil {
+				size = int(opt.UDPSize())
+			}
+			resp.Truncate(size)
+		}
+		_ = w.WriteMsg(resp)
+	})
+
+	servers := []*dns.Server{
+		{PacketConn: pc, Handler: handler},
+		{Listener: ln, Handler: handler},
+	}
+	for _, server := range servers {
+		go func() {
+			_ = server.ActivateAndServe()
+		}()
+		t.Cleanup(func() {
+			_ = server.Shutdown()
+		})
+	}
+
+	return pc.LocalAddr().String(), &queries
+}
+
+// unusedAddr returns a local UDP address that nothing is listening on.
+func unusedAddr(t *testing.T) string {
+	t.Helper()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	addr := pc.LocalAddr().String()
+	require.NoError(t, pc.Close())
+
+	return addr
+}
+
+func TestDNSResolver(t *testing.T) {
+	zone := testZone{"fqdn.example.org.": {"00:a", "01:b"}}
+	addr, _ := startDNSServer(t, zone)
+	servfail, _ := startDNSServer(t, testZone{"servfail.example.org.": nil})
+
+	resolver, err := NewDNSResolver([]string{unusedAddr(t), addr},
+		WithQueryTimeout(time.Second),
+	)
+	require.NoError(t, err)
+
+	answer, err := resolver.LookupTXTRecord(context.Background(), "fqdn.example.org")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"00:a", "01:b"}, answer.Lines)
+	assert.Equal(t, 300*time.Second, answer.TTL)
+	assert.Equal(t, dns.RcodeSuccess, answer.Rcode)
+	assert.False(t, answer.Authenticated)
+	require.NotNil(t, answer.Msg)
+
+	lines, err := resolver.LookupTXT(context.Background(), "fqdn.example.org")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"00:a", "01:b"}, lines)
+
+	_, err = resolver.LookupTXT(context.Background(), "missing.example.org")
+	var dnsErr *net.DNSError
+	require.ErrorAs(t, err, &dnsErr)
+	assert.True(t, dnsErr.IsNotFound)
+	var rcodeErr *RcodeError
+	require.ErrorAs(t, err, &rcodeErr)
+	assert.Equal(t, dns.RcodeNameError, rcodeErr.Rcode)
+	assert.NotNil(t, rcodeErr.Msg)
+
+	// SERVFAIL is skipped, and reported if nothing else answers.
+	resolver, err = NewDNSResolver([]string{servfail, addr})
+	require.NoError(t, err)
+
+	_, err = resolver.LookupTXT(context.Background(), "servfail.example.org")
+	require.ErrorAs(t, err, &dnsErr)
+	assert.True(t, dnsErr.IsTemporary)
+	require.ErrorAs(t, err, &rcodeErr)
+	assert.Equal(t, dns.RcodeServerFailure, rcodeErr.Rcode)
+}
+
+func TestTXTRecordRcode(t *testing.T) {
+	tests := []struct {
+		name         string
+		rcode        int
+		wantNotFound bool
+		wantTemp     bool
+	}{
+		{
+			name:         "NXDOMAIN",
+			rcode:        dns.RcodeNameError,
+			wantNotFound: true,
+		}, {
+			name:     "SERVFAIL",
+			rcode:    dns.RcodeServerFailure,
+			wantTemp: true,
+		}, {
+			name:  "REFUSED",
+			rcode: dns.RcodeRefused,
+		}, {
+			name:  "NOTIMP",
+			rcode: dns.RcodeNotImplemented,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := new(dns.Msg)
+			msg.SetQuestion("fqdn.example.org.", dns.TypeTXT)
+			msg.Rcode = tt.rcode
+
+			answer, err := txtRecord("fqdn.example.org", "10.0.0.1:53", msg)
+			assert.Nil(t, answer)
+
+			var dnsErr *net.DNSError
+			require.ErrorAs(t, err, &dnsErr)
+			assert.Equal(t, tt.wantNotFound, dnsErr.IsNotFound)
+			assert.Equal(t, tt.wantTemp, dnsErr.IsTemporary)
+			assert.Equal(t, "10.0.0.1:53", dnsErr.Server)
+
+			var rcodeErr *RcodeError
+			require.ErrorAs(t, err, &rcodeErr)
+			assert.Equal(t, tt.rcode, rcodeErr.Rcode)
+			assert.Same(t, msg, rcodeErr.Msg)
+		})
+	}
+}
+
+func TestNewDNSResolver(t *testing.T) {
+	_, err := NewDNSResolver(nil)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	_, err = NewDNSResolver([]string{""})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	resolver, err := NewDNSResolver([]string{"10.0.0.1", "[::1]", "10.0.0.2:5353"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1:53", "[::1]:53", "10.0.0.2:5353"}, resolver.servers)
+}
+
+func TestFetchUsesTXTRecordTTL(t *testing.T) {
+	// A public key JWT is small enough to fit in a UDP response.
+	a, err := MakePublicKeySet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+
+	record, err := a.resolver.LookupTXT(context.Background(), a.fqdn)
+	require.NoError(t, err)
+
+	addr, queries := startDNSServer(t, testZone{"fqdn.example.org.": record})
+
+	resolver, err := NewDNSResolver([]string{addr})
+	require.NoError(t, err)
+
+	fetcher, err := New(
+		WithFQDN(a.fqdn),
+		WithResolver(resolver),
+		WithParseOptions(a.provider),
+		WithCache(time.Hour),
+	)
+	require.NoError(t, err)
+
+	clock := time.Now()
+	fetcher.clock = jwt.ClockFunc(func() time.Time { return clock })
+
+	for range 3 {
+		_, buf, err := fetcher.Fetch(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, a.payload, buf)
+	}
+	assert.Equal(t, int64(1), queries.Load())
+
+	// The TTL of 300s is shorter than the max age.
+	clock = clock.Add(301 * time.Second)
+
+	_, _, err = fetcher.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), queries.Load())
+}
+
+func TestDNSResolverTruncated(t *testing.T) {
+	// A JWT with a certificate chain is too large for a UDP response.
+	a, err := MakeTrustedSet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+
+	record, err := a.resolver.LookupTXT(context.Background(), a.fqdn)
+	require.NoError(t, err)
+
+	addr, queries := startDNSServer(t, testZone{"fqdn.example.org.": record})
+
+	tests := []struct {
+		name        string
+		opts        []DNSOption
+		wantQueries int64
+	}{
+		{
+			name:        "truncated and sent again over TCP",
+			wantQueries: 2,
+		}, {
+			name:        "fits in a larger UDP buffer",
+			opts:        []DNSOption{WithUDPSize(4096)},
+			wantQueries: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries.Store(0)
+
+			fetcher, err := New(
+				WithFQDN(a.fqdn),
+				WithNameservers([]string{addr}, tt.opts...),
+				WithParseOptions(a.provider),
+			)
+			require.NoError(t, err)
+
+			_, buf, err := fetcher.Fetch(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, a.payload, buf)
+			assert.Equal(t, tt.wantQueries, queries.Load())
+		})
+	}
+}
+
+func TestDNSResolverSelection(t *testing.T) {
+	zone := testZone{"fqdn.example.org.": {"00:a"}}
+	first, firstQueries := startDNSServer(t, zone)
+	second, secondQueries := startDNSServer(t, zone)
+
+	tests := []struct {
+		name       string
+		opts       []DNSOption
+		wantFirst  int64
+		wantSecond int64
+	}{
+		{
+			name:       "ordered",
+			wantFirst:  4,
+			wantSecond: 0,
+		}, {
+			name:       "round-robin",
+			opts:       []DNSOption{WithRoundRobin()},
+			wantFirst:  2,
+			wantSecond: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			firstQueries.Store(0)
+			secondQueries.Store(0)
+
+			resolver, err := NewDNSResolver([]string{first, second}, tt.opts...)
+			require.NoError(t, err)
+
+			for range 4 {
+				lines, err := resolver.LookupTXT(context.Background(), "fqdn.example.org")
+				require.NoError(t, err)
+				assert.Equal(t, []string{"00:a"}, lines)
+			}
+
+			assert.Equal(t, tt.wantFirst, firstQueries.Load())
+			assert.Equal(t, tt.wantSecond, secondQueries.Load())
+		})
+	}
+
+	// A failing nameserver is skipped with round-robin selection too.
+	resolver, err := NewDNSResolver([]string{unusedAddr(t), first},
+		WithRoundRobin(),
+		WithQueryTimeout(time.Second),
+	)
+	require.NoError(t, err)
+
+	for range 2 {
+		_, err := resolver.LookupTXT(context.Background(), "fqdn.example.org")
+		require.NoError(t, err)
+	}
+}
+
+func TestWithNameserversInvalid(t *testing.T) {
+	_, err := New(WithFQDN("fqdn.example.org"), WithNameservers(nil))
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	_, err = New(WithFQDN("fqdn.example.org"), WithNameservers([]string{"10.0.0.1"}, WithUDPSize(100)))
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	resolver, err := NewDNSResolver([]string{"10.0.0.1"})
+	require.NoError(t, err)
+	assert.Equal(t, uint16(defaultUDPSize), resolver.udpSize)
+}
+
+func TestTXTRecordOwner(t *testing.T) {
+	cname := &dns.CNAME{
+		Hdr:    dns.RR_Header{Name: "alias.example.org.", Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 300},
+		Target: "fqdn.example.org.",
+	}
+
+	tests := []struct {
+		name    string
+		qname   string
+		answer  []dns.RR
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "only the records of the name",
+			qname:  "fqdn.example.org",
+			answer: append(txtRRs("FQDN.example.org.", []string{"00:a"}), txtRRs("other.example.org.", []string{"00:b"})...),
+			want:   []string{"00:a"},
+		}, {
+			name:   "the target of a CNAME",
+			qname:  "alias.example.org",
+			answer: append([]dns.RR{cname}, txtRRs("fqdn.example.org.", []string{"00:a"})...),
+			want:   []string{"00:a"},
+		}, {
+			name:    "no records of the name",
+			qname:   "fqdn.example.org",
+			answer:  txtRRs("other.example.org.", []string{"00:b"}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := new(dns.Msg)
+			msg.SetQuestion(dns.Fqdn(tt.qname), dns.TypeTXT)
+			msg.Answer = tt.answer
+
+			answer, err := txtRecord(tt.qname, "", msg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, answer.Lines)
+		})
+	}
+}
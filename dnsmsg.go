@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// newTXTQuery creates a recursive TXT query for the name.
+func newTXTQuery(name string, udpSize uint16) *dns.Msg {
+	var msg dns.Msg
+
+	msg.SetQuestion(dns.Fqdn(name), dns.TypeTXT)
+	msg.RecursionDesired = true
+	// Ask a validating resolver to report if the answer is authenticated.
+	msg.AuthenticatedData = true
+	msg.SetEdns0(udpSize, false)
+
+	return &msg
+}
+
+// txtRecord converts the response into a TXTResult, where the lines are in
+// the same form as net.Resolver.LookupTXT returns them: the character strings
+// of each record are joined.  Response codes are mapped to a *net.DNSError.
+func txtRecord(name, server string, msg *dns.Msg) (*TXTResult, error) {
+	if err := rcodeError(name, server, msg); err != nil {
+		return nil, err
+	}
+
+	answer := TXTResult{
+		Rcode:         msg.Rcode,
+		Authenticated: msg.AuthenticatedData,
+		Msg:           msg,
+	}
+
+	// Only the records of the name, or of the target of a CNAME for it, are
+	// part of the answer.
+	owners := map[string]bool{dns.CanonicalName(name): true}
+	for _, rr := range msg.Answer {
+		if cname, ok := rr.(*dns.CNAME); ok && owners[dns.CanonicalName(cname.Hdr.Name)] {
+			owners[dns.CanonicalName(cname.Target)] = true
+		}
+	}
+
+	for _, rr := range msg.Answer {
+		txt, ok := rr.(*dns.TXT)
+		if !ok || !owners[dns.CanonicalName(txt.Hdr.Name)] {
+			continue
+		}
+
+		answer.Lines = append(answer.Lines, strings.Join(txt.Txt, ""))
+
+		ttl := time.Duration(txt.Hdr.Ttl) * time.Second
+		if answer.TTL == 0 || ttl < answer.TTL {
+			answer.TTL = ttl
+		}
+	}
+
+	if len(answer.Lines) == 0 {
+		return nil, &net.DNSError{
+			Err:        "no TXT records found",
+			Name:       name,
+			Server:     server,
+			IsNotFound: true,
+		}
+	}
+
+	return &answer, nil
+}
+
+// lookupTXTRecord sends a TXT query for the name using the exchange function
+// and converts the response.
+func lookupTXTRecord(ctx context.Context, name, server string, udpSize uint16,
+	exchange func(context.Context, *dns.Msg) (*dns.Msg, error),
+) (*TXTResult, error) {
+	msg, err := exchange(ctx, newTXTQuery(name, udpSize))
+	if err != nil {
+		return nil, exchangeError(ctx, name, server, err)
+	}
+
+	return txtRecord(name, server, msg)
+}
+
+// RcodeError is the failed response code of a response.  It is wrapped by
+// the *net.DNSError returned for the response, so it can be found with
+// errors.As.
+type RcodeError struct {
+	// Rcode is the response code.
+	Rcode int
+
+	// Msg is the full response.
+	Msg *dns.Msg
+}
+
+func (e *RcodeError) Error() string {
+	return "response code " + dns.RcodeToString[e.Rcode]
+}
+
+// rcodeError maps a failed response code to a *net.DNSError wrapping a
+// *RcodeError.
+func rcodeError(name, server string, msg *dns.Msg) error {
+	dnsErr := net.DNSError{
+		UnwrapErr: &RcodeError{Rcode: msg.Rcode, Msg: msg},
+		Name:      name,
+		Server:    server,
+	}
+
+	switch msg.Rcode {
+	case dns.RcodeSuccess:
+		return nil
+	case dns.RcodeNameError:
+		dnsErr.Err = "no such host"
+		dnsErr.IsNotFound = true
+	case dns.RcodeServerFailure:
+		dnsErr.Err = "server misbehaving"
+		dnsErr.IsTemporary = true
+	default:
+		dnsErr.Err = "unexpected response code " + dns.RcodeToString[msg.Rcode]
+	}
+
+	return &dnsErr
+}
+
+// exchangeError wraps a transport error in a *net.DNSError so timeouts are
+// reported the same way as they are by net.Resolver.
+func exchangeError(ctx context.Context, name, server string, err error) error {
+	var netErr net.Error
+	timeout := errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(ctx.Err(), context.DeadlineExceeded) ||
+		(errors.As(err, &netErr) && netErr.Timeout())
+
+	return &net.DNSError{
+		UnwrapErr: err,
+		Err:       err.Error(),
+		Name:      name,
+		Server:    server,
+		IsTimeout: timeout,
+	}
+}
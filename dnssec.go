@@ -0,0 +1,328 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// maxChainDepth limits how many zones are walked up looking for a trust
+// anchor.
+const maxChainDepth = 16
+
+// Exchanger is an optional interface a Resolver may implement to send any
+// DNS query.  It is required to validate DNSSEC answers locally.
+type Exchanger interface {
+	// Exchange sends the query and returns the response.
+	Exchange(context.Context, *dns.Msg) (*dns.Msg, error)
+}
+
+// dnssecPolicy describes how answers must be authenticated.
+type dnssecPolicy struct {
+	// requireAD trusts the AD bit set by a validating resolver.
+	requireAD bool
+
+	// anchors are the trust anchors by zone, used to validate the RRSIG and
+	// DNSKEY chain locally.
+	anchors map[string][]*dns.DS
+}
+
+// newAnchors converts the DS and DNSKEY records into DS records by zone.
+func newAnchors(rrs []dns.RR) (map[string][]*dns.DS, error) {
+	anchors := make(map[string][]*dns.DS, len(rrs))
+
+	for _, rr := range rrs {
+		var ds *dns.DS
+		switch v := rr.(type) {
+		case *dns.DS:
+			ds = v
+		case *dns.DNSKEY:
+			ds = v.ToDS(dns.SHA256)
+		}
+		if ds == nil {
+			return nil, fmt.Errorf("%w trust anchors must be DS or DNSKEY records", ErrInvalidInput)
+		}
+
+		zone := dns.CanonicalName(rr.Header().Name)
+		anchors[zone] = append(anchors[zone], ds)
+	}
+
+	return anchors, nil
+}
+
+// validator validates the DNSSEC chain of trust of answers from a trust
+// anchor down to the records.
+type validator struct {
+	exchanger Exchanger
+	anchors   map[string][]*dns.DS
+	now       time.Time
+
+	// keys are the DNSKEY records already validated, by zone.
+	keys map[string][]*dns.DNSKEY
+}
+
+// lookupTXT sends a TXT query asking for the DNSSEC records and validates the
+// answer.
+func (v *validator) lookupTXT(ctx context.Context, name string) (*TXTResult, error) {
+	msg, err := v.query(ctx, name, dns.TypeTXT)
+	if err != nil {
+		return nil, exchangeError(ctx, name, "", err)
+	}
+
+	answer, err := txtRecord(name, "", msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := v.validate(ctx, msg.Answer); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrNotAuthenticated, name, err)
+	}
+
+	// The query is sent with the CD bit, so the AD bit isn't set even though
+	// the answer is now authenticated.
+	answer.Authenticated = true
+
+	return answer, nil
+}
+
+// query sends a query with the DO bit set so the RRSIG records are included,
+// and the CD bit set so a validating resolver returns the records even if it
+// can't validate them.
+func (v *validator) query(ctx context.Context, name string, qtype uint16) (*dns.Msg, error) {
+	var q dns.Msg
+
+	q.SetQuestion(dns.Fqdn(name), qtype)
+	q.RecursionDesired = true
+	q.CheckingDisabled = true
+	q.SetEdns0(dns.DefaultMsgSize, true)
+
+	return v.exchanger.Exchange(ctx, &q)
+}
+
+// validate checks that every RRset in the records has a valid signature.
+func (v *validator) validate(ctx context.Context, rrs []dns.RR) error {
+	sets, sigs := splitRRsets(rrs)
+	if len(sets) == 0 {
+		return errors.New("no records to validate")
+	}
+
+	for _, set := range sets {
+		if err := v.verifyRRset(ctx, set, sigs, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// verifyRRset checks that one of the signatures for the RRset is valid and
+// made by a validated key of the signer's zone.  The signer must be the owner
+// of the RRset or one of its ancestors, otherwise any zone with a chain of
+// trust could sign records for names outside of it.
+func (v *validator) verifyRRset(ctx context.Context, set []dns.RR, sigs []*dns.RRSIG, depth int) error {
+	hdr := set[0].Header()
+	owner := dns.CanonicalName(hdr.Name)
+	errs := []error{
+		fmt.Errorf("no valid signature for %s %s", hdr.Name, dns.TypeToString[hdr.Rrtype]),
+	}
+
+	for _, sig := range sigs {
+		if sig.TypeCovered != hdr.Rrtype || !strings.EqualFold(sig.Hdr.Name, hdr.Name) {
+			continue
+		}
+		if !dns.IsSubDomain(dns.CanonicalName(sig.SignerName), owner) {
+			errs = append(errs, fmt.Errorf("signature by %s can't cover %s", sig.SignerName, hdr.Name))
+			continue
+		}
+		if int(sig.Labels) > dns.CountLabel(owner) {
+			errs = append(errs, fmt.Errorf("signature by %s has more labels than %s", sig.SignerName, hdr.Name))
+			continue
+		}
+
+		keys, err := v.zoneKeys(ctx, sig.SignerName, depth)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if err := v.verifyWith(sig, keys, set); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		return nil
+	}
+
+	return errors.Join(errs...)
+}
+
+// verifyWith checks the signature of the RRset with the matching key.
+func (v *validator) verifyWith(sig *dns.RRSIG, keys []*dns.DNSKEY, set []dns.RR) error {
+	if !sig.ValidityPeriod(v.now) {
+		return fmt.Errorf("signature by %s (key %d) is outside its validity period", sig.SignerName, sig.KeyTag)
+	}
+
+	for _, key := range keys {
+		if key.KeyTag() != sig.KeyTag || key.Algorithm != sig.Algorithm {
+			continue
+		}
+		if err := sig.Verify(key, set); err == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("signature by %s (key %d) did not verify", sig.SignerName, sig.KeyTag)
+}
+
+// zoneKeys returns the validated DNSKEY records of the zone.  The keys are
+// trusted if a key signing the DNSKEY RRset matches a trust anchor, or a DS
+// record that is validated by the parent zone.
+func (v *validator) zoneKeys(ctx context.Context, zone string, depth int) ([]*dns.DNSKEY, error) {
+	zone = dns.CanonicalName(zone)
+	if keys, found := v.keys[zone]; found {
+		return keys, nil
+	}
+	if depth > maxChainDepth {
+		return nil, errors.New("chain of trust is too long")
+	}
+
+	msg, err := v.query(ctx, zone, dns.TypeDNSKEY)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []*dns.DNSKEY
+	var keySet []dns.RR
+	for _, rr := range msg.Answer {
+		if key, ok := rr.(*dns.DNSKEY); ok && strings.EqualFold(key.Hdr.Name, zone) {
+			keys = append(keys, key)
+			keySet = append(keySet, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no DNSKEY records for %s", zone)
+	}
+
+	dsSet, err := v.delegation(ctx, zone, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	var trusted []*dns.DNSKEY
+	for _, key := range keys {
+		for _, ds := range dsSet {
+			if matchesDS(key, ds) {
+				trusted = append(trusted, key)
+			}
+		}
+	}
+	if len(trusted) == 0 {
+		return nil, fmt.Errorf("no DNSKEY for %s matches a trusted DS record", zone)
+	}
+
+	_, sigs := splitRRsets(msg.Answer)
+	for _, sig := range sigs {
+		if sig.TypeCovered != dns.TypeDNSKEY || dns.CanonicalName(sig.SignerName) != zone {
+			continue
+		}
+		if v.verifyWith(sig, trusted, keySet) == nil {
+			if v.keys == nil {
+				v.keys = make(map[string][]*dns.DNSKEY)
+			}
+			v.keys[zone] = keys
+			return keys, nil
+		}
+	}
+
+	return nil, fmt.Errorf("the DNSKEY records for %s are not signed by a trusted key", zone)
+}
+
+// delegation returns the trusted DS records for the zone, either from the
+// trust anchors or from the parent zone.
+func (v *validator) delegation(ctx context.Context, zone string, depth int) ([]*dns.DS, error) {
+	if anchors, found := v.anchors[zone]; found {
+		return anchors, nil
+	}
+	if zone == "." {
+		return nil, errors.New("no trust anchor found")
+	}
+
+	msg, err := v.query(ctx, zone, dns.TypeDS)
+	if err != nil {
+		return nil, err
+	}
+
+	var dsSet []*dns.DS
+	var set []dns.RR
+	for _, rr := range msg.Answer {
+		if ds, ok := rr.(*dns.DS); ok && strings.EqualFold(ds.Hdr.Name, zone) {
+			dsSet = append(dsSet, ds)
+			set = append(set, ds)
+		}
+	}
+	if len(dsSet) == 0 {
+		return nil, fmt.Errorf("no DS records for %s", zone)
+	}
+
+	// The DS records must be signed by an ancestor of the zone, otherwise the
+	// zone could vouch for itself.
+	var sigs []*dns.RRSIG
+	_, all := splitRRsets(msg.Answer)
+	for _, sig := range all {
+		signer := dns.CanonicalName(sig.SignerName)
+		if signer != zone && dns.IsSubDomain(signer, zone) {
+			sigs = append(sigs, sig)
+		}
+	}
+
+	if err := v.verifyRRset(ctx, set, sigs, depth+1); err != nil {
+		return nil, err
+	}
+
+	return dsSet, nil
+}
+
+// matchesDS reports if the key is the one described by the DS record.
+func matchesDS(key *dns.DNSKEY, ds *dns.DS) bool {
+	if key.KeyTag() != ds.KeyTag || key.Algorithm != ds.Algorithm {
+		return false
+	}
+
+	got := key.ToDS(ds.DigestType)
+
+	return got != nil && strings.EqualFold(got.Digest, ds.Digest)
+}
+
+// splitRRsets groups the records into RRsets by name and type, and returns
+// the signatures separately.
+func splitRRsets(rrs []dns.RR) ([][]dns.RR, []*dns.RRSIG) {
+	var sets [][]dns.RR
+	var sigs []*dns.RRSIG
+	index := make(map[string]int)
+
+	for _, rr := range rrs {
+		if sig, ok := rr.(*dns.RRSIG); ok {
+			sigs = append(sigs, sig)
+			continue
+		}
+
+		hdr := rr.Header()
+		key := dns.CanonicalName(hdr.Name) + "/" + dns.TypeToString[hdr.Rrtype]
+		i, found := index[key]
+		if !found {
+			i = len(sets)
+			index[key] = i
+			sets = append(sets, nil)
+		}
+		sets[i] = append(sets[i], rr)
+	}
+
+	return sets, sigs
+}
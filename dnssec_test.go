@@ -0,0 +1,359 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"crypto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signedZone holds the keys of a zone for signing records.
+type signedZone struct {
+	name     string
+	ksk, zsk *dns.DNSKEY
+	kskPriv  crypto.Signer
+	zskPriv  crypto.Signer
+}
+
+func newSignedZone(t *testing.T, name string) *signedZone {
+	t.Helper()
+
+	newKey := func(flags uint16) (*dns.DNSKEY, crypto.Signer) {
+		key := &dns.DNSKEY{
+			Hdr: dns.RR_Header{
+				Name:   name,
+				Rrtype: dns.TypeDNSKEY,
+				Class:  dns.ClassINET,
+				Ttl:    3600,
+			},
+			Flags:     flags,
+			Protocol:  3,
+			Algorithm: dns.ECDSAP256SHA256,
+		}
+		priv, err := key.Generate(256)
+		require.NoError(t, err)
+		return key, priv.(crypto.Signer)
+	}
+
+	z := signedZone{name: name}
+	z.ksk, z.kskPriv = newKey(dns.ZONE | dns.SEP)
+	z.zsk, z.zskPriv = newKey(dns.ZONE)
+
+	return &z
+}
+
+func (z *signedZone) sign(t *testing.T, set []dns.RR, ksk bool, now time.Time) *dns.RRSIG {
+	t.Helper()
+
+	key, priv := z.zsk, z.zskPriv
+	if ksk {
+		key, priv = z.ksk, z.kskPriv
+	}
+
+	sig := &dns.RRSIG{
+		Hdr:        dns.RR_Header{Ttl: set[0].Header().Ttl},
+		Inception:  uint32(now.Add(-time.Hour).Unix()),
+		Expiration: uint32(now.Add(time.Hour).Unix()),
+		KeyTag:     key.KeyTag(),
+		SignerName: z.name,
+		Algorithm:  key.Algorithm,
+	}
+	require.NoError(t, sig.Sign(priv, set))
+
+	return sig
+}
+
+// dnssecServer answers queries from a set of records, acting as both a
+// Resolver and an Exchanger.
+type dnssecServer struct {
+	records map[string][]dns.RR
+}
+
+func (s *dnssecServer) add(rrs ...dns.RR) {
+	if s.records == nil {
+		s.records = make(map[string][]dns.RR)
+	}
+	for _, rr := range rrs {
+		hdr := rr.Header()
+		rtype := hdr.Rrtype
+		if sig, ok := rr.(*dns.RRSIG); ok {
+			rtype = sig.TypeCovered
+		}
+		key := dns.CanonicalName(hdr.Name) + "/" + dns.TypeToString[rtype]
+		s.records[key] = append(s.records[key], rr)
+	}
+}
+
+func (s *dnssecServer) Exchange(_ context.Context, query *dns.Msg) (*dns.Msg, error) {
+	var resp dns.Msg
+	resp.SetReply(query)
+
+	q := query.Question[0]
+	resp.Answer = s.records[dns.CanonicalName(q.Name)+"/"+dns.TypeToString[q.Qtype]]
+	if len(resp.Answer) == 0 {
+		resp.Rcode = dns.RcodeNameError
+	}
+
+	return &resp, nil
+}
+
+func (s *dnssecServer) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	msg, err := s.Exchange(ctx, newTXTQuery(name, dns.DefaultMsgSize))
+	if err != nil {
+		return nil, err
+	}
+
+	answer, err := txtRecord(name, "", msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return answer.Lines, nil
+}
+
+func txtRRs(name string, lines []string) []dns.RR {
+	rrs := make([]dns.RR, 0, len(lines))
+	for _, line := range lines {
+		rrs = append(rrs, &dns.TXT{
+			Hdr: dns.RR_Header{
+				Name:   name,
+				Rrtype: dns.TypeTXT,
+				Class:  dns.ClassINET,
+				Ttl:    300,
+			},
+			Txt: []string{line},
+		})
+	}
+	return rrs
+}
+
+func TestDNSSECTrustAnchors(t *testing.T) {
+	now := time.Now()
+
+	a, err := MakePublicKeySet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+
+	record, err := a.resolver.LookupTXT(context.Background(), a.fqdn)
+	require.NoError(t, err)
+
+	org := newSignedZone(t, "org.")
+	example := newSignedZone(t, "example.org.")
+	attacker := newSignedZone(t, "attacker.org.")
+	other := newSignedZone(t, "org.")
+
+	// build creates the signed records, with the TXT records signed by the
+	// signer, letting each test case alter them.
+	build := func(signer *signedZone, alter func(txt []dns.RR)) *dnssecServer {
+		var s dnssecServer
+
+		for _, z := range []*signedZone{org, example, attacker} {
+			keys := []dns.RR{z.ksk, z.zsk}
+			s.add(keys...)
+			s.add(z.sign(t, keys, true, now))
+		}
+
+		for _, z := range []*signedZone{example, attacker} {
+			ds := []dns.RR{z.ksk.ToDS(dns.SHA256)}
+			s.add(ds...)
+			s.add(org.sign(t, ds, false, now))
+		}
+
+		txt := txtRRs("fqdn.example.org.", record)
+		s.add(signer.sign(t, txt, false, now))
+		if alter != nil {
+			alter(txt)
+		}
+		s.add(txt...)
+
+		return &s
+	}
+
+	tests := []struct {
+		name      string
+		server    *dnssecServer
+		anchors   []dns.RR
+		requireAD bool
+		advance   time.Duration
+		wantErr   bool
+	}{
+		{
+			name:    "validated from the parent zone",
+			server:  build(example, nil),
+			anchors: []dns.RR{org.ksk},
+		}, {
+			name:      "validated and the AD bit required",
+			server:    build(example, nil),
+			anchors:   []dns.RR{org.ksk},
+			requireAD: true,
+		}, {
+			name:    "signed by another zone with a chain of trust",
+			server:  build(attacker, nil),
+			anchors: []dns.RR{org.ksk},
+			wantErr: true,
+		}, {
+			name:    "signed by the parent zone",
+			server:  build(org, nil),
+			anchors: []dns.RR{org.ksk},
+		}, {
+			name:    "validated with a DS trust anchor for the zone",
+			server:  build(example, nil),
+			anchors: []dns.RR{example.ksk.ToDS(dns.SHA256)},
+		}, {
+			name:    "the trust anchor doesn't match",
+			server:  build(example, nil),
+			anchors: []dns.RR{other.ksk},
+			wantErr: true,
+		}, {
+			name:    "no trust anchor for the chain",
+			server:  build(example, nil),
+			anchors: []dns.RR{newSignedZone(t, "example.com.").ksk},
+			wantErr: true,
+		}, {
+			name:   "the record was tampered with",
+			server: build(example, func(txt []dns.RR) { txt[0].(*dns.TXT).Txt[0] = "00:tampered" }),
+			anchors: []dns.RR{
+				org.ksk,
+			},
+			wantErr: true,
+		}, {
+			name:    "the signatures expired",
+			server:  build(example, nil),
+			anchors: []dns.RR{org.ksk},
+			advance: 2 * time.Hour,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []FetcherOption{
+				WithFQDN(a.fqdn),
+				WithResolver(tt.server),
+				WithParseOptions(a.provider),
+				WithDNSSECTrustAnchors(tt.anchors...),
+			}
+			if tt.requireAD {
+				opts = append(opts, WithRequireAD())
+			}
+			fetcher, err := New(opts...)
+			require.NoError(t, err)
+			fetcher.clock = jwt.ClockFunc(func() time.Time { return now.Add(tt.advance) })
+
+			_, buf, err := fetcher.Fetch(context.Background())
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrNotAuthenticated)
+				assert.Nil(t, buf)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, a.payload, buf)
+		})
+	}
+}
+
+func TestDNSSECUnsignedAndUnsupported(t *testing.T) {
+	a, err := MakePublicKeySet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+
+	record, err := a.resolver.LookupTXT(context.Background(), a.fqdn)
+	require.NoError(t, err)
+
+	org := newSignedZone(t, "org.")
+
+	var unsigned dnssecServer
+	unsigned.add(txtRRs("fqdn.example.org.", record)...)
+
+	for _, resolver := range []Resolver{&unsigned, a.resolver} {
+		fetcher, err := New(
+			WithFQDN(a.fqdn),
+			WithResolver(resolver),
+			WithParseOptions(a.provider),
+			WithDNSSECTrustAnchors(org.ksk),
+		)
+		require.NoError(t, err)
+
+		_, _, err = fetcher.Fetch(context.Background())
+		assert.ErrorIs(t, err, ErrNotAuthenticated)
+	}
+
+	_, err = New(WithFQDN(a.fqdn), WithDNSSECTrustAnchors())
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	_, err = New(WithFQDN(a.fqdn), WithDNSSECTrustAnchors(&dns.A{Hdr: dns.RR_Header{Name: "org."}}))
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+// adResolver answers with the record and a fixed AD bit.
+type adResolver struct {
+	lines         []string
+	authenticated bool
+}
+
+func (r adResolver) LookupTXT(context.Context, string) ([]string, error) {
+	return r.lines, nil
+}
+
+func (r adResolver) LookupTXTRecord(context.Context, string) (*TXTResult, error) {
+	return &TXTResult{Lines: r.lines, Authenticated: r.authenticated}, nil
+}
+
+func TestRequireAD(t *testing.T) {
+	a, err := MakePublicKeySet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+
+	record, err := a.resolver.LookupTXT(context.Background(), a.fqdn)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		resolver Resolver
+		wantErr  bool
+	}{
+		{
+			name:     "authenticated",
+			resolver: adResolver{lines: record, authenticated: true},
+		}, {
+			name:     "not authenticated",
+			resolver: adResolver{lines: record},
+			wantErr:  true,
+		}, {
+			name:     "the resolver doesn't report the AD bit",
+			resolver: a.resolver,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher, err := New(
+				WithFQDN(a.fqdn),
+				WithResolver(tt.resolver),
+				WithParseOptions(a.provider),
+				WithRequireAD(),
+				WithServeStale(0),
+			)
+			require.NoError(t, err)
+
+			_, buf, err := fetcher.Fetch(context.Background())
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrNotAuthenticated)
+				assert.True(t, strings.Contains(err.Error(), "AD bit"))
+				assert.Nil(t, buf)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, a.payload, buf)
+		})
+	}
+}
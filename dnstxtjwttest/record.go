@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dnstxtjwttest provides helpers for testing code that uses
+// dnstxtjwt: signed records with their trust chain, a fake Resolver that
+// injects faults, and a controllable clock.
+package dnstxtjwttest
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/lestrrat-go/jwx/v2/cert"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/xmidt-org/dnstxtjwt"
+	"github.com/xmidt-org/jwskeychain"
+	"github.com/xmidt-org/jwskeychain/keychaintest"
+)
+
+// Record is a signed JWT split into the lines of a TXT record, along with the
+// trust chain needed to verify it.
+type Record struct {
+	// FQDN is the name the record is published at.
+	FQDN string
+
+	// JWT is the signed JWT.
+	JWT []byte
+
+	// Payload is the payload of the JWT, as returned by Fetcher.Fetch.
+	Payload []byte
+
+	// Lines are the lines of the TXT record.
+	Lines []string
+
+	// Chain is the certificate chain the JWT is signed with.
+	Chain keychaintest.Chain
+
+	// KeyProvider verifies the JWT against the root of the chain.  Pass it to
+	// dnstxtjwt.WithParseOptions.
+	KeyProvider jwt.ParseOption
+}
+
+// NewRecord creates a new certificate chain, signs a JWT with the claims
+// using the leaf certificate and splits it into a TXT record for the FQDN.
+func NewRecord(fqdn string, claims map[string]any, opts ...dnstxtjwt.CreateOption) (*Record, error) {
+	chain, err := keychaintest.New(keychaintest.Desc("leaf<-ica<-root"))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRecordWithChain(chain, fqdn, claims, opts...)
+}
+
+// NewRecordWithChain signs a JWT with the claims using the leaf certificate
+// of the chain and splits it into a TXT record for the FQDN.  Records created
+// with the same chain are verified by the same KeyProvider.
+func NewRecordWithChain(chain keychaintest.Chain, fqdn string, claims map[string]any, opts ...dnstxtjwt.CreateOption) (*Record, error) {
+	provider, err := jwskeychain.New(jwskeychain.TrustedRoots(chain.Root().Public))
+	if err != nil {
+		return nil, err
+	}
+
+	signed, err := SignJWT(chain, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := dnstxtjwt.CreateRecord(string(signed), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := jws.Parse(signed)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Record{
+		FQDN:        fqdn,
+		JWT:         signed,
+		Payload:     msg.Payload(),
+		Lines:       lines,
+		Chain:       chain,
+		KeyProvider: jwt.WithKeyProvider(provider),
+	}, nil
+}
+
+// SignJWT signs a JWT with the claims using the leaf certificate of the
+// chain.  The certificates are included in the x5c header.
+func SignJWT(chain keychaintest.Chain, claims map[string]any) ([]byte, error) {
+	var x5c cert.Chain
+	for _, c := range chain.Included() {
+		if err := x5c.AddString(base64.URLEncoding.EncodeToString(c.Raw)); err != nil {
+			return nil, err
+		}
+	}
+
+	token := jwt.New()
+	for k, v := range claims {
+		if err := token.Set(k, v); err != nil {
+			return nil, fmt.Errorf("claim %q: %w", k, err)
+		}
+	}
+
+	headers := jws.NewHeaders()
+	if err := headers.Set(jws.X509CertChainKey, &x5c); err != nil {
+		return nil, err
+	}
+
+	return jwt.Sign(token,
+		jwt.WithKey(
+			jwa.ES256,
+			chain.Leaf().Private,
+			jws.WithProtectedHeaders(headers),
+		),
+	)
+}
+
+// Resolver returns a new fake Resolver serving the record.
+func (r *Record) Resolver() *Resolver {
+	var resolver Resolver
+	resolver.Set(r.FQDN, r.Lines)
+
+	return &resolver
+}
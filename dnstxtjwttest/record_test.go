@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwttest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/dnstxtjwt"
+)
+
+func TestNewRecord(t *testing.T) {
+	rec, err := NewRecord("fqdn.example.org", map[string]any{"example": "A"},
+		dnstxtjwt.WithMaxLineLength(100),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, "fqdn.example.org", rec.FQDN)
+	assert.NotEmpty(t, rec.JWT)
+	assert.Contains(t, string(rec.Payload), `"example":"A"`)
+	for _, line := range rec.Lines {
+		assert.LessOrEqual(t, len(line), 100)
+	}
+
+	token, err := jwt.Parse(rec.JWT, rec.KeyProvider)
+	require.NoError(t, err)
+	example, ok := token.Get("example")
+	assert.True(t, ok)
+	assert.Equal(t, "A", example)
+
+	// A record signed by another chain isn't trusted.
+	other, err := NewRecord("fqdn.example.org", map[string]any{"example": "B"})
+	require.NoError(t, err)
+
+	_, err = jwt.Parse(other.JWT, rec.KeyProvider)
+	assert.Error(t, err)
+
+	_, err = NewRecord("fqdn.example.org", map[string]any{"exp": "not a time"})
+	assert.Error(t, err)
+}
+
+func TestClockExpiry(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	clock := NewClock(now)
+
+	rec, err := NewRecord("fqdn.example.org", map[string]any{
+		"nbf": now.Add(-time.Minute),
+		"exp": now.Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	fetcher, err := dnstxtjwt.New(
+		dnstxtjwt.WithFQDN(rec.FQDN),
+		dnstxtjwt.WithResolver(rec.Resolver()),
+		dnstxtjwt.WithParseOptions(rec.KeyProvider),
+		dnstxtjwt.WithCache(0),
+		dnstxtjwt.WithClock(clock),
+	)
+	require.NoError(t, err)
+
+	_, buf, err := fetcher.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, rec.Payload, buf)
+
+	// The cached token expires along with the clock.
+	clock.Advance(2 * time.Hour)
+
+	_, _, err = fetcher.Fetch(context.Background())
+	assert.ErrorIs(t, err, dnstxtjwt.ErrInvalidJWT)
+
+	// Before the nbf claim, the token isn't valid yet.
+	clock.Set(now.Add(-time.Hour))
+	assert.Equal(t, now.Add(-time.Hour), clock.Now())
+
+	_, _, err = fetcher.Fetch(context.Background())
+	assert.ErrorIs(t, err, dnstxtjwt.ErrInvalidJWT)
+}
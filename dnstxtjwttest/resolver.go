@@ -0,0 +1,267 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwttest
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// server is the name reported in the errors of the Resolver.
+const server = "dnstxtjwttest"
+
+// Failure is a DNS failure a lookup returns.
+type Failure int
+
+const (
+	// NoFailure answers normally.
+	NoFailure Failure = iota
+
+	// NXDOMAIN answers that the name doesn't exist.
+	NXDOMAIN
+
+	// SERVFAIL answers with a temporary server failure.
+	SERVFAIL
+
+	// Timeout fails with a timeout.
+	Timeout
+)
+
+// Fault describes how lookups of a name misbehave.  The changes to the
+// lines are applied in the order of the fields.
+type Fault struct {
+	// Count is the number of lookups the fault applies to.  Zero applies it to
+	// every lookup until Clear is called.
+	Count int
+
+	// Latency delays the answer.  If the context ends first, the lookup fails
+	// with a timeout.
+	Latency time.Duration
+
+	// Failure fails the lookup with a DNS failure.
+	Failure Failure
+
+	// Err fails the lookup with the error.
+	Err error
+
+	// Swap replaces the record while the lookup is in flight.  The answer
+	// holds the lines of the old record before the middle and the lines of
+	// Swap from there on, like a resolver caught mid-update.  Later lookups
+	// answer with Swap.
+	Swap []string
+
+	// Drop are the indexes of the lines left out of the answer.
+	Drop []int
+
+	// Duplicate are the indexes of the lines repeated in the answer.
+	Duplicate []int
+
+	// Reorder reverses the order of the lines.
+	Reorder bool
+}
+
+// Resolver is a fake dnstxtjwt.Resolver serving records from memory, with
+// faults injected into the lookups.  The zero value is ready to use, and it
+// is safe for concurrent use.
+type Resolver struct {
+	mu      sync.Mutex
+	records map[string][]string
+	faults  map[string][]*Fault
+	lookups map[string]int
+}
+
+// Set sets the lines of the record for the name.
+func (r *Resolver) Set(fqdn string, lines []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.records == nil {
+		r.records = make(map[string][]string)
+	}
+	r.records[key(fqdn)] = slices.Clone(lines)
+}
+
+// Delete removes the record for the name.
+func (r *Resolver) Delete(fqdn string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.records, key(fqdn))
+}
+
+// Inject queues faults for the lookups of the name.  Each fault applies to
+// the next Count lookups, then the next fault is used.
+func (r *Resolver) Inject(fqdn string, faults ...Fault) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.faults == nil {
+		r.faults = make(map[string][]*Fault)
+	}
+	for _, f := range faults {
+		r.faults[key(fqdn)] = append(r.faults[key(fqdn)], &f)
+	}
+}
+
+// Clear removes the queued faults of the name.
+func (r *Resolver) Clear(fqdn string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.faults, key(fqdn))
+}
+
+// Lookups returns the number of lookups of the name.
+func (r *Resolver) Lookups(fqdn string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.lookups[key(fqdn)]
+}
+
+// LookupTXT returns the lines of the record for the name, after applying the
+// next fault.
+func (r *Resolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	fault, lines, found := r.start(name)
+
+	if fault.Latency > 0 {
+		timer := time.NewTimer(fault.Latency)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, &net.DNSError{
+				UnwrapErr: ctx.Err(),
+				Err:       ctx.Err().Error(),
+				Name:      name,
+				Server:    server,
+				IsTimeout: true,
+			}
+		case <-timer.C:
+		}
+	}
+
+	if fault.Err != nil {
+		return nil, fault.Err
+	}
+	if err := failure(name, fault.Failure); err != nil {
+		return nil, err
+	}
+
+	if fault.Swap != nil {
+		lines = r.swap(name, lines, fault.Swap)
+		found = true
+	}
+
+	if !found {
+		return nil, failure(name, NXDOMAIN)
+	}
+
+	return fault.alter(lines), nil
+}
+
+// start counts the lookup, takes the next fault and returns the record.
+func (r *Resolver) start(name string) (Fault, []string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := key(name)
+
+	if r.lookups == nil {
+		r.lookups = make(map[string]int)
+	}
+	r.lookups[k]++
+
+	var fault Fault
+	if queue := r.faults[k]; len(queue) > 0 {
+		fault = *queue[0]
+		if queue[0].Count > 0 {
+			queue[0].Count--
+			if queue[0].Count == 0 {
+				r.faults[k] = queue[1:]
+			}
+		}
+	}
+
+	lines, found := r.records[k]
+
+	return fault, slices.Clone(lines), found
+}
+
+// swap replaces the record and returns the mix of the old and new lines.
+func (r *Resolver) swap(name string, old, lines []string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.records == nil {
+		r.records = make(map[string][]string)
+	}
+	r.records[key(name)] = slices.Clone(lines)
+
+	middle := len(old) / 2
+	mixed := slices.Clone(old[:middle])
+	if middle < len(lines) {
+		mixed = append(mixed, lines[middle:]...)
+	}
+
+	return mixed
+}
+
+// alter drops, duplicates and reorders the lines.
+func (f Fault) alter(lines []string) []string {
+	altered := make([]string, 0, len(lines)+len(f.Duplicate))
+	for i, line := range lines {
+		if slices.Contains(f.Drop, i) {
+			continue
+		}
+		altered = append(altered, line)
+		if slices.Contains(f.Duplicate, i) {
+			altered = append(altered, line)
+		}
+	}
+
+	if f.Reorder {
+		slices.Reverse(altered)
+	}
+
+	return altered
+}
+
+// failure returns the error for the failure, in the form net.Resolver
+// returns it.
+func failure(name string, f Failure) error {
+	switch f {
+	case NXDOMAIN:
+		return &net.DNSError{
+			Err:        "no such host",
+			Name:       name,
+			Server:     server,
+			IsNotFound: true,
+		}
+	case SERVFAIL:
+		return &net.DNSError{
+			Err:         "server misbehaving",
+			Name:        name,
+			Server:      server,
+			IsTemporary: true,
+		}
+	case Timeout:
+		return &net.DNSError{
+			Err:       "i/o timeout",
+			Name:      name,
+			Server:    server,
+			IsTimeout: true,
+		}
+	}
+
+	return nil
+}
+
+// key returns the name in the form records are stored by.
+func key(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
@@ -0,0 +1,199 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwttest
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/dnstxtjwt"
+)
+
+func TestResolverFaults(t *testing.T) {
+	lines := []string{"00:a", "01:b", "02:c", "03:d"}
+	errUnknown := errors.New("unknown")
+
+	tests := []struct {
+		name       string
+		fqdn       string
+		fault      Fault
+		timeout    time.Duration
+		want       []string
+		wantErr    error
+		notFound   bool
+		temporary  bool
+		timedOut   bool
+		wantRecord []string
+	}{
+		{
+			name: "no fault",
+			want: lines,
+		}, {
+			name:     "missing record",
+			fqdn:     "missing.example.org",
+			notFound: true,
+		}, {
+			name:     "NXDOMAIN",
+			fault:    Fault{Failure: NXDOMAIN},
+			notFound: true,
+		}, {
+			name:      "SERVFAIL",
+			fault:     Fault{Failure: SERVFAIL},
+			temporary: true,
+		}, {
+			name:     "timeout",
+			fault:    Fault{Failure: Timeout},
+			timedOut: true,
+		}, {
+			name:    "error",
+			fault:   Fault{Err: errUnknown},
+			wantErr: errUnknown,
+		}, {
+			name:  "latency",
+			fault: Fault{Latency: 10 * time.Millisecond},
+			want:  lines,
+		}, {
+			name:     "latency past the deadline",
+			fault:    Fault{Latency: time.Minute},
+			timeout:  10 * time.Millisecond,
+			timedOut: true,
+		}, {
+			name:  "dropped lines",
+			fault: Fault{Drop: []int{1, 3}},
+			want:  []string{"00:a", "02:c"},
+		}, {
+			name:  "duplicated lines",
+			fault: Fault{Duplicate: []int{0}},
+			want:  []string{"00:a", "00:a", "01:b", "02:c", "03:d"},
+		}, {
+			name:  "reordered lines",
+			fault: Fault{Reorder: true},
+			want:  []string{"03:d", "02:c", "01:b", "00:a"},
+		}, {
+			name:       "swapped mid-flight",
+			fault:      Fault{Swap: []string{"00:w", "01:x", "02:y", "03:z"}},
+			want:       []string{"00:a", "01:b", "02:y", "03:z"},
+			wantRecord: []string{"00:w", "01:x", "02:y", "03:z"},
+		}, {
+			name:       "swapped into a missing record",
+			fqdn:       "missing.example.org",
+			fault:      Fault{Swap: []string{"00:w"}},
+			want:       []string{"00:w"},
+			wantRecord: []string{"00:w"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r Resolver
+			r.Set("fqdn.example.org.", lines)
+
+			fqdn := tt.fqdn
+			if fqdn == "" {
+				fqdn = "FQDN.example.org"
+			}
+			r.Inject(fqdn, tt.fault)
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			got, err := r.LookupTXT(ctx, fqdn)
+			assert.Equal(t, 1, r.Lookups(fqdn))
+
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.notFound || tt.temporary || tt.timedOut:
+				var dnsErr *net.DNSError
+				require.ErrorAs(t, err, &dnsErr)
+				assert.Equal(t, tt.notFound, dnsErr.IsNotFound)
+				assert.Equal(t, tt.temporary, dnsErr.IsTemporary)
+				assert.Equal(t, tt.timedOut, dnsErr.IsTimeout)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			// A swap replaces the record for later lookups.
+			if tt.wantRecord != nil {
+				r.Clear(fqdn)
+				got, err := r.LookupTXT(context.Background(), fqdn)
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantRecord, got)
+			}
+		})
+	}
+}
+
+func TestResolverFaultQueue(t *testing.T) {
+	var r Resolver
+	r.Set("fqdn.example.org", []string{"00:a"})
+	r.Inject("fqdn.example.org",
+		Fault{Count: 2, Failure: SERVFAIL},
+		Fault{Count: 1, Failure: NXDOMAIN},
+	)
+
+	for _, want := range []Failure{SERVFAIL, SERVFAIL, NXDOMAIN, NoFailure} {
+		_, err := r.LookupTXT(context.Background(), "fqdn.example.org")
+		assert.Equal(t, failure("fqdn.example.org", want), err)
+	}
+	assert.Equal(t, 4, r.Lookups("fqdn.example.org"))
+
+	r.Delete("fqdn.example.org")
+	_, err := r.LookupTXT(context.Background(), "fqdn.example.org")
+	var dnsErr *net.DNSError
+	require.ErrorAs(t, err, &dnsErr)
+	assert.True(t, dnsErr.IsNotFound)
+}
+
+func TestResolverWithFetcher(t *testing.T) {
+	a, err := NewRecord("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+	// The new record is split differently, so a mix of the two is garbled.
+	b, err := NewRecordWithChain(a.Chain, "fqdn.example.org", map[string]any{"example": "B"},
+		dnstxtjwt.WithMaxLineLength(100),
+	)
+	require.NoError(t, err)
+
+	r := a.Resolver()
+
+	fetcher, err := dnstxtjwt.New(
+		dnstxtjwt.WithFQDN(a.FQDN),
+		dnstxtjwt.WithResolver(r),
+		dnstxtjwt.WithParseOptions(a.KeyProvider),
+	)
+	require.NoError(t, err)
+
+	// Duplicated and reordered lines still reassemble.
+	r.Inject(a.FQDN, Fault{Count: 1, Duplicate: []int{0}, Reorder: true})
+
+	_, buf, err := fetcher.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, a.Payload, buf)
+
+	// A dropped line does not.
+	r.Inject(a.FQDN, Fault{Count: 1, Drop: []int{1}})
+
+	_, _, err = fetcher.Fetch(context.Background())
+	assert.ErrorIs(t, err, dnstxtjwt.ErrInvalidJWT)
+
+	// A lookup caught mid-update fails, and the next one gets the new record.
+	r.Inject(a.FQDN, Fault{Count: 1, Swap: b.Lines})
+
+	_, _, err = fetcher.Fetch(context.Background())
+	assert.ErrorIs(t, err, dnstxtjwt.ErrInvalidJWT)
+
+	_, buf, err = fetcher.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, b.Payload, buf)
+}
@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/miekg/dns"
+)
+
+// dohMediaType is the media type of DNS messages defined by RFC 8484.
+const dohMediaType = "application/dns-message"
+
+// maxDoHResponse limits how much of a response is read.  A DNS message can't
+// be larger than 64KiB.
+const maxDoHResponse = 65535
+
+// DoHResolver is a Resolver that performs lookups using DNS-over-HTTPS as
+// defined by RFC 8484.
+type DoHResolver struct {
+	endpoint string
+	client   *http.Client
+	method   string
+}
+
+// DoHOption is the interface that all DoHResolver options must implement.
+type DoHOption interface {
+	apply(*DoHResolver) error
+}
+
+// NewDoHResolver creates a new DoHResolver that sends queries to the endpoint,
+// for example "https://dns.example.org/dns-query".
+func NewDoHResolver(endpoint string, opts ...DoHOption) (*DoHResolver, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("%w invalid DoH endpoint %q", ErrInvalidInput, endpoint)
+	}
+
+	d := DoHResolver{
+		endpoint: endpoint,
+	}
+
+	defaults := []DoHOption{ // nolint:prealloc
+		WithHTTPClient(nil),
+		WithHTTPMethod(""),
+	}
+
+	opts = append(defaults, opts...)
+
+	for _, opt := range opts {
+		if opt != nil {
+			if err := opt.apply(&d); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &d, nil
+}
+
+// LookupTXT returns the DNS TXT records for the given domain name.
+func (d *DoHResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	answer, err := d.LookupTXTRecord(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return answer.Lines, nil
+}
+
+// LookupTXTRecord returns the DNS TXT records for the given domain name along
+// with the details of the response.
+func (d *DoHResolver) LookupTXTRecord(ctx context.Context, name string) (*TXTResult, error) {
+	return lookupTXTRecord(ctx, name, d.endpoint, dns.DefaultMsgSize, d.Exchange)
+}
+
+// Exchange sends the query to the endpoint and returns the response.
+func (d *DoHResolver) Exchange(ctx context.Context, query *dns.Msg) (*dns.Msg, error) {
+	// RFC 8484 recommends an ID of 0 so responses are cache friendly.
+	q := query.Copy()
+	q.Id = 0
+
+	packed, err := q.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := d.newRequest(ctx, packed)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected DoH response status: %s", resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != dohMediaType {
+		return nil, fmt.Errorf("unexpected DoH response content type: %q", ct)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDoHResponse))
+	if err != nil {
+		return nil, err
+	}
+
+	var msg dns.Msg
+	if err := msg.Unpack(body); err != nil {
+		return nil, err
+	}
+	msg.Id = query.Id
+
+	return &msg, nil
+}
+
+func (d *DoHResolver) newRequest(ctx context.Context, packed []byte) (*http.Request, error) {
+	if d.method == http.MethodGet {
+		u, err := url.Parse(d.endpoint)
+		if err != nil {
+			return nil, err
+		}
+
+		values := u.Query()
+		values.Set("dns", base64.RawURLEncoding.EncodeToString(packed))
+		u.RawQuery = values.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", dohMediaType)
+
+		return req, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.endpoint, bytes.NewReader(packed))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", dohMediaType)
+	req.Header.Set("Content-Type", dohMediaType)
+
+	return req, nil
+}
@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"fmt"
+	"net/http"
+)
+
+type dohOptionFunc func(*DoHResolver) error
+
+func (f dohOptionFunc) apply(d *DoHResolver) error {
+	return f(d)
+}
+
+// WithHTTPClient sets the HTTP client used to send DoH queries.  If the client
+// is nil or this option is unset, the http.DefaultClient is used.
+func WithHTTPClient(client *http.Client) DoHOption {
+	return dohOptionFunc(
+		func(d *DoHResolver) error {
+			if client == nil {
+				client = http.DefaultClient
+			}
+			d.client = client
+			return nil
+		},
+	)
+}
+
+// WithHTTPMethod sets the HTTP method used to send DoH queries.  Either
+// http.MethodGet or http.MethodPost may be used.  An empty method sets the
+// default of http.MethodGet, which is the most cache friendly.
+func WithHTTPMethod(method string) DoHOption {
+	return dohOptionFunc(
+		func(d *DoHResolver) error {
+			switch method {
+			case "":
+				method = http.MethodGet
+			case http.MethodGet, http.MethodPost:
+			default:
+				return fmt.Errorf("%w unsupported DoH method %q", ErrInvalidInput, method)
+			}
+			d.method = method
+			return nil
+		},
+	)
+}
@@ -0,0 +1,193 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testZone answers TXT queries from a map of names to the lines of the
+// record.  The name "servfail.example.org." answers with SERVFAIL and all
+// other names answer with NXDOMAIN.
+type testZone map[string][]string
+
+func (z testZone) answer(query *dns.Msg) *dns.Msg {
+	var resp dns.Msg
+	resp.SetReply(query)
+
+	name := query.Question[0].Name
+	if name == "servfail.example.org." {
+		resp.Rcode = dns.RcodeServerFailure
+		return &resp
+	}
+
+	lines, found := z[name]
+	if !found {
+		resp.Rcode = dns.RcodeNameError
+		return &resp
+	}
+
+	for _, line := range lines {
+		resp.Answer = append(resp.Answer, &dns.TXT{
+			Hdr: dns.RR_Header{
+				Name:   name,
+				Rrtype: dns.TypeTXT,
+				Class:  dns.ClassINET,
+				Ttl:    300,
+			},
+			Txt: []string{line},
+		})
+	}
+
+	return &resp
+}
+
+func (z testZone) dohHandler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var packed []byte
+		var err error
+
+		switch req.Method {
+		case http.MethodGet:
+			packed, err = base64.RawURLEncoding.DecodeString(req.URL.Query().Get("dns"))
+		case http.MethodPost:
+			assert.Equal(t, dohMediaType, req.Header.Get("Content-Type"))
+			packed, err = io.ReadAll(req.Body)
+		}
+		if err != nil || len(packed) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var query dns.Msg
+		if err := query.Unpack(packed); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		assert.Equal(t, uint16(0), query.Id)
+
+		resp, err := z.answer(&query).Pack()
+		require.NoError(t, err)
+
+		w.Header().Set("Content-Type", dohMediaType)
+		_, _ = w.Write(resp)
+	})
+}
+
+func TestDoHResolver(t *testing.T) {
+	a, err := MakeTrustedSet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+
+	record, err := a.resolver.LookupTXT(context.Background(), a.fqdn)
+	require.NoError(t, err)
+
+	zone := testZone{"fqdn.example.org.": record}
+	server := httptest.NewTLSServer(zone.dohHandler(t))
+	defer server.Close()
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		t.Run(method, func(t *testing.T) {
+			resolver, err := NewDoHResolver(server.URL+"/dns-query",
+				WithHTTPClient(server.Client()),
+				WithHTTPMethod(method),
+			)
+			require.NoError(t, err)
+
+			lines, err := resolver.LookupTXT(context.Background(), "fqdn.example.org")
+			require.NoError(t, err)
+			assert.Equal(t, record, lines)
+
+			answer, err := resolver.LookupTXTRecord(context.Background(), "fqdn.example.org")
+			require.NoError(t, err)
+			assert.Equal(t, record, answer.Lines)
+			assert.Equal(t, 300*time.Second, answer.TTL)
+
+			_, err = resolver.LookupTXT(context.Background(), "missing.example.org")
+			var dnsErr *net.DNSError
+			require.ErrorAs(t, err, &dnsErr)
+			assert.True(t, dnsErr.IsNotFound)
+
+			_, err = resolver.LookupTXT(context.Background(), "servfail.example.org")
+			require.ErrorAs(t, err, &dnsErr)
+			assert.True(t, dnsErr.IsTemporary)
+
+			fetcher, err := New(
+				WithFQDN(a.fqdn),
+				WithResolver(resolver),
+				WithParseOptions(a.provider),
+			)
+			require.NoError(t, err)
+
+			_, buf, err := fetcher.Fetch(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, a.payload, buf)
+		})
+	}
+}
+
+func TestDoHResolverErrors(t *testing.T) {
+	wrongType := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer wrongType.Close()
+
+	badStatus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer badStatus.Close()
+
+	garbage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", dohMediaType)
+		_, _ = w.Write([]byte{0x01})
+	}))
+	defer garbage.Close()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer slow.Close()
+
+	for _, url := range []string{wrongType.URL, badStatus.URL, garbage.URL} {
+		resolver, err := NewDoHResolver(url)
+		require.NoError(t, err)
+
+		lines, err := resolver.LookupTXT(context.Background(), "fqdn.example.org")
+		assert.Nil(t, lines)
+		assert.Error(t, err)
+	}
+
+	resolver, err := NewDoHResolver(slow.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = resolver.LookupTXT(ctx, "fqdn.example.org")
+	var dnsErr *net.DNSError
+	require.ErrorAs(t, err, &dnsErr)
+	assert.True(t, dnsErr.IsTimeout)
+}
+
+func TestNewDoHResolverInvalid(t *testing.T) {
+	_, err := NewDoHResolver("not a url")
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	_, err = NewDoHResolver("https://dns.example.org/dns-query", WithHTTPMethod(http.MethodPut))
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
@@ -0,0 +1,360 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+var (
+	errConnClosed = errors.New("DoT connection closed")
+
+	// errNoResponse is a timeout, so it is reported the same way as one.
+	errNoResponse = fmt.Errorf("DoT server didn't respond: %w", context.DeadlineExceeded)
+)
+
+// DoTResolver is a Resolver that performs lookups using DNS-over-TLS as
+// defined by RFC 7858.  A single connection is reused for all queries, and
+// queries are pipelined over it.  If a query times out without anything
+// having been read from the connection since the query was sent, the server
+// is assumed to be gone and the connection is dropped.
+type DoTResolver struct {
+	addr            string
+	tlsConfig       *tls.Config
+	dialTimeout     time.Duration
+	responseTimeout time.Duration
+	fallback        Resolver
+
+	mu   sync.Mutex
+	conn *dotConn
+}
+
+// DoTOption is the interface that all DoTResolver options must implement.
+type DoTOption interface {
+	apply(*DoTResolver) error
+}
+
+// NewDoTResolver creates a new DoTResolver that sends queries to the server
+// at addr.  If addr doesn't include a port, port 853 is used.  Unless set by
+// an option, the host of addr is used as the TLS server name.
+func NewDoTResolver(addr string, opts ...DoTOption) (*DoTResolver, error) {
+	if addr == "" {
+		return nil, fmt.Errorf("%w DoT address must be set", ErrInvalidInput)
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+		addr = net.JoinHostPort(addr, "853")
+	}
+
+	d := DoTResolver{
+		addr:      addr,
+		tlsConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+
+	defaults := []DoTOption{ // nolint:prealloc
+		WithDialTimeout(0),
+		WithResponseTimeout(0),
+	}
+
+	opts = append(defaults, opts...)
+
+	for _, opt := range opts {
+		if opt != nil {
+			if err := opt.apply(&d); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if d.tlsConfig.ServerName == "" {
+		d.tlsConfig.ServerName = host
+	}
+
+	return &d, nil
+}
+
+// LookupTXT returns the DNS TXT records for the given domain name.  If the
+// query fails because of a TLS or connection error, or the server doesn't
+// respond in time, and a fallback resolver is set, the fallback resolver is
+// used instead.
+func (d *DoTResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	answer, err := d.LookupTXTRecord(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return answer.Lines, nil
+}
+
+// LookupTXTRecord returns the DNS TXT records for the given domain name along
+// with the details of the response.  If the query fails because of a TLS or
+// connection error, or the server doesn't respond in time, and a fallback
+// resolver is set, the fallback resolver is used instead.  Only the lines are
+// known when the fallback resolver doesn't implement TXTRecordResolver.
+func (d *DoTResolver) LookupTXTRecord(ctx context.Context, name string) (*TXTResult, error) {
+	msg, err := d.Exchange(ctx, newTXTQuery(name, dns.DefaultMsgSize))
+	if err != nil {
+		// The fallback is pointless once the caller has given up.
+		if d.fallback != nil && !contextDone(ctx) {
+			return lookupRecord(ctx, d.fallback, name)
+		}
+		return nil, exchangeError(ctx, name, d.addr, err)
+	}
+
+	return txtRecord(name, d.addr, msg)
+}
+
+// contextDone reports if the context is done, including when its deadline
+// has passed but the context hasn't been canceled yet.
+func contextDone(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+
+	deadline, ok := ctx.Deadline()
+	return ok && !time.Now().Before(deadline)
+}
+
+// Exchange sends the query over the shared connection and returns the
+// response.  If the connection was closed by the server, a new connection is
+// made and the query is sent again once.  If the server doesn't respond
+// within the response timeout, an error is returned without trying again.
+func (d *DoTResolver) Exchange(ctx context.Context, query *dns.Msg) (*dns.Msg, error) {
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		var conn *dotConn
+		conn, err = d.getConn(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var msg *dns.Msg
+		msg, err = conn.exchange(ctx, query, d.responseTimeout)
+		if err == nil {
+			return msg, nil
+		}
+
+		// The shared connection is fine if only this query gave up, and was
+		// already closed by the exchange if the server went silent.  Asking a
+		// silent server again would only double the wait.
+		if ctx.Err() != nil || errors.Is(err, errNoResponse) {
+			return nil, err
+		}
+		d.dropConn(conn)
+	}
+
+	return nil, err
+}
+
+// Close closes the shared connection, if any.
+func (d *DoTResolver) Close() error {
+	d.mu.Lock()
+	conn := d.conn
+	d.conn = nil
+	d.mu.Unlock()
+
+	if conn != nil {
+		return conn.close(errConnClosed)
+	}
+
+	return nil
+}
+
+// getConn returns the shared connection, dialing a new one if needed.
+func (d *DoTResolver) getConn(ctx context.Context) (*dotConn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.conn != nil && !d.conn.closed() {
+		return d.conn, nil
+	}
+
+	dialer := tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: d.dialTimeout},
+		Config:    d.tlsConfig,
+	}
+
+	c, err := dialer.DialContext(ctx, "tcp", d.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	d.conn = newDoTConn(c)
+
+	return d.conn, nil
+}
+
+// dropConn forgets the connection if it is still the shared one and closes
+// it.
+func (d *DoTResolver) dropConn(conn *dotConn) {
+	d.mu.Lock()
+	if d.conn == conn {
+		d.conn = nil
+	}
+	d.mu.Unlock()
+
+	_ = conn.close(errConnClosed)
+}
+
+// dotConn is a connection that supports pipelined queries.  Responses are
+// matched to queries by the message ID.
+type dotConn struct {
+	conn *dns.Conn
+
+	// wmu serializes the writes.
+	wmu sync.Mutex
+
+	// reads counts the responses read, to tell a silent server from a slow
+	// response.
+	reads atomic.Uint64
+
+	mu      sync.Mutex
+	pending map[uint16]chan *dns.Msg
+	err     error
+	done    chan struct{}
+}
+
+func newDoTConn(c net.Conn) *dotConn {
+	conn := dotConn{
+		conn:    &dns.Conn{Conn: c},
+		pending: make(map[uint16]chan *dns.Msg),
+		done:    make(chan struct{}),
+	}
+
+	go conn.read()
+
+	return &conn
+}
+
+// exchange sends the query and waits for the response for up to the timeout.
+// If the wait times out and nothing was read since the query was sent, the
+// connection is closed.
+func (c *dotConn) exchange(ctx context.Context, query *dns.Msg, timeout time.Duration) (*dns.Msg, error) {
+	q := query.Copy()
+	ch := make(chan *dns.Msg, 1)
+
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	for {
+		q.Id = dns.Id()
+		if _, found := c.pending[q.Id]; !found {
+			break
+		}
+	}
+	c.pending[q.Id] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, q.Id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(ctx, q); err != nil {
+		_ = c.close(err)
+		return nil, err
+	}
+	reads := c.reads.Load()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case msg := <-ch:
+		msg.Id = query.Id
+		return msg, nil
+	case <-c.done:
+		return nil, c.err
+	case <-timer.C:
+		c.timedOut(reads)
+		return nil, errNoResponse
+	case <-ctx.Done():
+		// Only a timeout says anything about the server.
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			c.timedOut(reads)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// timedOut closes the connection if nothing was read since the count of reads
+// was taken.
+func (c *dotConn) timedOut(reads uint64) {
+	if c.reads.Load() == reads {
+		_ = c.close(errNoResponse)
+	}
+}
+
+func (c *dotConn) write(ctx context.Context, q *dns.Msg) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	deadline, _ := ctx.Deadline()
+	if err := c.conn.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+
+	return c.conn.WriteMsg(q)
+}
+
+// read dispatches the responses to the waiting queries until the connection
+// fails.
+func (c *dotConn) read() {
+	for {
+		msg, err := c.conn.ReadMsg()
+		if err != nil {
+			_ = c.close(err)
+			return
+		}
+
+		c.reads.Add(1)
+
+		c.mu.Lock()
+		ch, found := c.pending[msg.Id]
+		c.mu.Unlock()
+
+		// Drop duplicate responses rather than blocking.
+		if found {
+			select {
+			case ch <- msg:
+			default:
+			}
+		}
+	}
+}
+
+func (c *dotConn) closed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err != nil
+}
+
+// close closes the connection, recording the reason for any waiting queries.
+func (c *dotConn) close(reason error) error {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil
+	}
+	c.err = reason
+	close(c.done)
+	c.mu.Unlock()
+
+	return c.conn.Close()
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"time"
+)
+
+type dotOptionFunc func(*DoTResolver) error
+
+func (f dotOptionFunc) apply(d *DoTResolver) error {
+	return f(d)
+}
+
+// WithTLSConfig sets the TLS configuration used to connect to the server.  The
+// configuration is cloned, and options that follow this one modify the clone.
+func WithTLSConfig(cfg *tls.Config) DoTOption {
+	return dotOptionFunc(
+		func(d *DoTResolver) error {
+			if cfg != nil {
+				d.tlsConfig = cfg.Clone()
+			}
+			return nil
+		},
+	)
+}
+
+// WithServerName sets the name sent using SNI and used to verify the server's
+// certificate.
+func WithServerName(name string) DoTOption {
+	return dotOptionFunc(
+		func(d *DoTResolver) error {
+			d.tlsConfig.ServerName = name
+			return nil
+		},
+	)
+}
+
+// WithRootCAs sets the root certificates used to verify the server's
+// certificate.  If unset, the system roots are used.
+func WithRootCAs(pool *x509.CertPool) DoTOption {
+	return dotOptionFunc(
+		func(d *DoTResolver) error {
+			d.tlsConfig.RootCAs = pool
+			return nil
+		},
+	)
+}
+
+// WithDialTimeout sets the timeout for connecting to the server.  Any value of
+// zero or less sets the default of 5s.
+func WithDialTimeout(timeout time.Duration) DoTOption {
+	return dotOptionFunc(
+		func(d *DoTResolver) error {
+			if timeout <= 0 {
+				timeout = 5 * time.Second
+			}
+			d.dialTimeout = timeout
+			return nil
+		},
+	)
+}
+
+// WithResponseTimeout sets how long to wait for the response to a query.  If
+// nothing at all is read from the connection in that time, the connection is
+// dropped and the next query makes a new one.  Any value of zero or less sets
+// the default of 5s.
+func WithResponseTimeout(timeout time.Duration) DoTOption {
+	return dotOptionFunc(
+		func(d *DoTResolver) error {
+			if timeout <= 0 {
+				timeout = 5 * time.Second
+			}
+			d.responseTimeout = timeout
+			return nil
+		},
+	)
+}
+
+// WithFallback sets the resolver used when the query can't be sent over TLS,
+// for example because the handshake failed or the server can't be reached, or
+// when the server doesn't respond within the response timeout.
+func WithFallback(resolver Resolver) DoTOption {
+	return dotOptionFunc(
+		func(d *DoTResolver) error {
+			d.fallback = resolver
+			return nil
+		},
+	)
+}
@@ -0,0 +1,332 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCertificate creates a self signed certificate for dns.example.org and
+// 127.0.0.1, and a pool that trusts it.
+func testCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "dns.example.org"},
+		DNSNames:              []string{"dns.example.org"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, key.Public(), key)
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}, pool
+}
+
+// countingListener counts the connections accepted.
+type countingListener struct {
+	net.Listener
+	accepted atomic.Int64
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err == nil {
+		l.accepted.Add(1)
+	}
+	return c, err
+}
+
+// startDoTServer starts a DNS-over-TLS server answering from the zone.  The
+// name "close.example.org." is answered and then the connection is closed.
+func startDoTServer(t *testing.T, zone testZone, cert tls.Certificate) (string, *countingListener) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	counter := &countingListener{Listener: ln}
+	tlsLn := tls.NewListener(counter, &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	})
+
+	server := dns.Server{
+		Listener: tlsLn,
+		Net:      "tcp-tls",
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, query *dns.Msg) {
+			_ = w.WriteMsg(zone.answer(query))
+			if query.Question[0].Name == "close.example.org." {
+				_ = w.Close()
+			}
+		}),
+	}
+
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	t.Cleanup(func() {
+		_ = server.Shutdown()
+	})
+
+	return ln.Addr().String(), counter
+}
+
+func TestDoTResolver(t *testing.T) {
+	a, err := MakeTrustedSet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+
+	record, err := a.resolver.LookupTXT(context.Background(), a.fqdn)
+	require.NoError(t, err)
+
+	cert, pool := testCertificate(t)
+	zone := testZone{
+		"fqdn.example.org.":  record,
+		"close.example.org.": {"00:closing"},
+	}
+	addr, counter := startDoTServer(t, zone, cert)
+
+	resolver, err := NewDoTResolver(addr,
+		WithServerName("dns.example.org"),
+		WithRootCAs(pool),
+	)
+	require.NoError(t, err)
+	defer resolver.Close()
+
+	// Concurrent queries are pipelined over one connection.
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lines, err := resolver.LookupTXT(context.Background(), "fqdn.example.org")
+			assert.NoError(t, err)
+			assert.Equal(t, record, lines)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(1), counter.accepted.Load())
+
+	_, err = resolver.LookupTXT(context.Background(), "missing.example.org")
+	var dnsErr *net.DNSError
+	require.ErrorAs(t, err, &dnsErr)
+	assert.True(t, dnsErr.IsNotFound)
+
+	// The server closes the connection, so the next query reconnects.
+	lines, err := resolver.LookupTXT(context.Background(), "close.example.org")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"00:closing"}, lines)
+
+	fetcher, err := New(
+		WithFQDN(a.fqdn),
+		WithResolver(resolver),
+		WithParseOptions(a.provider),
+	)
+	require.NoError(t, err)
+
+	_, buf, err := fetcher.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, a.payload, buf)
+	assert.Equal(t, int64(2), counter.accepted.Load())
+}
+
+func TestDoTResolverFallback(t *testing.T) {
+	cert, _ := testCertificate(t)
+	addr, _ := startDoTServer(t, testZone{}, cert)
+
+	fallback := resolverFunc(func(context.Context, string) ([]string, error) {
+		return []string{"00:fallback"}, nil
+	})
+
+	// The server's certificate isn't trusted, so the handshake fails.
+	resolver, err := NewDoTResolver(addr,
+		WithTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12}),
+		WithRootCAs(x509.NewCertPool()),
+		WithFallback(fallback),
+	)
+	require.NoError(t, err)
+	defer resolver.Close()
+
+	lines, err := resolver.LookupTXT(context.Background(), "fqdn.example.org")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"00:fallback"}, lines)
+
+	// Without a fallback, the TLS error is returned.
+	resolver, err = NewDoTResolver(addr, WithRootCAs(x509.NewCertPool()))
+	require.NoError(t, err)
+	defer resolver.Close()
+
+	lines, err = resolver.LookupTXT(context.Background(), "fqdn.example.org")
+	assert.Nil(t, lines)
+	var dnsErr *net.DNSError
+	require.ErrorAs(t, err, &dnsErr)
+	var certErr *tls.CertificateVerificationError
+	assert.ErrorAs(t, err, &certErr)
+}
+
+// startSilentDoTServer starts a server that completes the TLS handshake and
+// then never responds.
+func startSilentDoTServer(t *testing.T, cert tls.Certificate) (string, *countingListener) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	counter := &countingListener{Listener: ln}
+	tlsLn := tls.NewListener(counter, &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	})
+
+	var (
+		mu    sync.Mutex
+		conns []net.Conn
+	)
+	t.Cleanup(func() {
+		_ = ln.Close()
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, c := range conns {
+			_ = c.Close()
+		}
+	})
+
+	go func() {
+		for {
+			c, err := tlsLn.Accept()
+			if err != nil {
+				return
+			}
+
+			mu.Lock()
+			conns = append(conns, c)
+			mu.Unlock()
+
+			// Reading completes the handshake.
+			go func() {
+				_, _ = io.Copy(io.Discard, c)
+			}()
+		}
+	}()
+
+	return ln.Addr().String(), counter
+}
+
+func TestDoTResolverSilentServer(t *testing.T) {
+	cert, pool := testCertificate(t)
+	addr, counter := startSilentDoTServer(t, cert)
+
+	var fallbacks atomic.Int64
+	fallback := resolverFunc(func(context.Context, string) ([]string, error) {
+		fallbacks.Add(1)
+		return []string{"00:fallback"}, nil
+	})
+
+	tests := []struct {
+		name          string
+		opts          []DoTOption
+		timeout       time.Duration
+		wantFallbacks int64
+	}{
+		{
+			name: "the response times out",
+			opts: []DoTOption{WithResponseTimeout(20 * time.Millisecond)},
+		}, {
+			name:          "the response times out with a fallback",
+			opts:          []DoTOption{WithResponseTimeout(20 * time.Millisecond), WithFallback(fallback)},
+			wantFallbacks: 3,
+		}, {
+			name:    "the caller times out",
+			opts:    []DoTOption{WithResponseTimeout(time.Hour), WithFallback(fallback)},
+			timeout: 20 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter.accepted.Store(0)
+			fallbacks.Store(0)
+
+			opts := append([]DoTOption{WithRootCAs(pool)}, tt.opts...)
+			resolver, err := NewDoTResolver(addr, opts...)
+			require.NoError(t, err)
+			defer resolver.Close()
+
+			// Each lookup gives up on the connection, so the next one makes
+			// a new connection instead of waiting on the dead one.
+			for range 3 {
+				ctx := context.Background()
+				if tt.timeout > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+					defer cancel()
+				}
+
+				lines, err := resolver.LookupTXT(ctx, "fqdn.example.org")
+				if tt.wantFallbacks > 0 {
+					require.NoError(t, err)
+					assert.Equal(t, []string{"00:fallback"}, lines)
+					continue
+				}
+
+				assert.Nil(t, lines)
+				var dnsErr *net.DNSError
+				require.ErrorAs(t, err, &dnsErr)
+				assert.True(t, dnsErr.IsTimeout)
+			}
+
+			assert.Equal(t, int64(3), counter.accepted.Load())
+			assert.Equal(t, tt.wantFallbacks, fallbacks.Load())
+		})
+	}
+}
+
+func TestNewDoTResolver(t *testing.T) {
+	_, err := NewDoTResolver("")
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	resolver, err := NewDoTResolver("dns.example.org")
+	require.NoError(t, err)
+	assert.Equal(t, "dns.example.org:853", resolver.addr)
+	assert.Equal(t, "dns.example.org", resolver.tlsConfig.ServerName)
+	assert.NoError(t, resolver.Close())
+}
@@ -3,9 +3,232 @@
 
 package dnstxtjwt
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
 
 var (
-	ErrInvalidJWT   = errors.New("invalid JWT")
-	ErrInvalidInput = errors.New("invalid input")
+	ErrInvalidJWT       = errors.New("invalid JWT")
+	ErrInvalidInput     = errors.New("invalid input")
+	ErrStale            = errors.New("stale JWT served")
+	ErrQuorum           = errors.New("quorum not reached")
+	ErrNotAuthenticated = errors.New("DNSSEC authentication failed")
+
+	// Lookup failures, mapped from *net.DNSError.
+	ErrNotFound      = errors.New("not found")
+	ErrServerFailure = errors.New("server failure")
+	ErrTimeout       = errors.New("timeout")
+
+	// Reassembly failures.  These also match ErrInvalidJWT.
+	ErrEmptyRecord       = errors.New("empty record")
+	ErrMalformedLine     = errors.New("malformed line")
+	ErrMissingChunk      = errors.New("missing chunk")
+	ErrDuplicateChunk    = errors.New("duplicate chunk")
+	ErrInconsistentIndex = errors.New("inconsistent index")
+
+	// Verification failures.  These also match ErrInvalidJWT.
+	ErrExpired      = errors.New("token expired")
+	ErrNotYetValid  = errors.New("token not yet valid")
+	ErrBadSignature = errors.New("bad signature")
+	ErrNameMismatch = errors.New("token is bound to another name")
+	ErrRollback     = errors.New("token is older than the last accepted one")
+)
+
+// Stage is the stage of a fetch that failed.
+type Stage string
+
+const (
+	// StageLookup is the DNS lookup of the TXT record.
+	StageLookup Stage = "lookup"
+
+	// StageAuthenticate is the DNSSEC authentication of the answer.
+	StageAuthenticate Stage = "authenticate"
+
+	// StageReassemble is the reassembly of the JWT from the lines.
+	StageReassemble Stage = "reassemble"
+
+	// StageVerify is the verification of the JWT.
+	StageVerify Stage = "verify"
 )
+
+// FetchError describes the failure to fetch the JWT from one FQDN.  Use
+// errors.Is with the sentinel errors to find out why it failed, and
+// errors.As to reach the cause, such as a *net.DNSError.
+type FetchError struct {
+	// FQDN is the name that failed.  It is empty if the record was obtained
+	// out of band, as with VerifyLines.
+	FQDN string
+
+	// Stage is the stage that failed.
+	Stage Stage
+
+	// Err is the cause.
+	Err error
+
+	// kinds are the sentinel errors describing the failure.
+	kinds []error
+}
+
+// newFetchError creates a FetchError, ignoring any nil kinds.
+func newFetchError(fqdn string, stage Stage, err error, kinds ...error) *FetchError {
+	e := FetchError{
+		FQDN:  fqdn,
+		Stage: stage,
+		Err:   err,
+	}
+
+	for _, kind := range kinds {
+		if kind != nil && !errors.Is(err, kind) {
+			e.kinds = append(e.kinds, kind)
+		}
+	}
+
+	return &e
+}
+
+func (e *FetchError) Error() string {
+	parts := make([]string, 0, len(e.kinds)+3)
+	if e.FQDN != "" {
+		parts = append(parts, e.FQDN)
+	}
+	parts = append(parts, string(e.Stage))
+	for _, kind := range e.kinds {
+		parts = append(parts, kind.Error())
+	}
+	parts = append(parts, e.Err.Error())
+
+	return strings.Join(parts, ": ")
+}
+
+// Unwrap returns the cause along with the sentinel errors.
+func (e *FetchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.kinds)+1)
+	errs = append(errs, e.Err)
+
+	return append(errs, e.kinds...)
+}
+
+// RecordError describes why the lines of a record couldn't be reassembled
+// into a JWT.  Each problem names the offending line, or the missing index.
+// Use errors.Is with the sentinel errors to find out the kinds of problems.
+type RecordError struct {
+	// Problems are the problems found, in the order they were found.
+	Problems []ReassemblyProblem
+
+	// empty is set if no line of the record has a chunk, or the chunks are
+	// all empty.
+	empty bool
+}
+
+// newRecordError creates a RecordError for the lines and the problems found
+// with them.
+func newRecordError(lines []string, problems []ReassemblyProblem) *RecordError {
+	malformed := 0
+	for _, p := range problems {
+		if p.Kind == MalformedLine {
+			malformed++
+		}
+	}
+
+	// Without any problem, the record reassembled into nothing.
+	return &RecordError{
+		Problems: problems,
+		empty:    malformed == len(lines) || len(problems) == 0,
+	}
+}
+
+func (e *RecordError) Error() string {
+	parts := make([]string, 0, len(e.Problems)+1)
+	if e.empty {
+		parts = append(parts, "empty record: the record has no chunks")
+	}
+	for _, p := range e.Problems {
+		parts = append(parts, p.String())
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// Unwrap returns the sentinel errors for the kinds of problems found.
+func (e *RecordError) Unwrap() []error {
+	var errs []error
+	if e.empty {
+		errs = append(errs, ErrEmptyRecord)
+	}
+	for _, p := range e.Problems {
+		if err := p.Kind.err(); err != nil && !slices.Contains(errs, err) {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// lookupKind returns the sentinel error for a failed lookup, if known.
+func lookupKind(err error) error {
+	// The errors of the individual resolvers don't explain a quorum that
+	// wasn't reached, which may be a sign of poisoning.
+	if errors.Is(err, ErrQuorum) {
+		return nil
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) {
+		return nil
+	}
+
+	switch {
+	case dnsErr.IsNotFound:
+		return ErrNotFound
+	case dnsErr.IsTimeout:
+		return ErrTimeout
+	case dnsErr.IsTemporary:
+		return ErrServerFailure
+	}
+
+	return nil
+}
+
+// verifyKind returns the sentinel error for a failed verification, if known.
+func verifyKind(err error) error {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired()):
+		return ErrExpired
+	case errors.Is(err, jwt.ErrTokenNotYetValid()):
+		return ErrNotYetValid
+	case jws.IsVerificationError(err):
+		return ErrBadSignature
+	}
+
+	return nil
+}
+
+// lookupFailed reports if the error is from the lookup of every name, which
+// makes serving a stale token appropriate.
+func lookupFailed(err error) bool {
+	switch e := err.(type) {
+	case *FetchError:
+		return e.Stage == StageLookup
+	case interface{ Unwrap() []error }:
+		errs := e.Unwrap()
+		for _, err := range errs {
+			if !lookupFailed(err) {
+				return false
+			}
+		}
+		return len(errs) > 0
+	}
+
+	return false
+}
@@ -0,0 +1,205 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchErrors(t *testing.T) {
+	const fqdn = "fqdn.example.org"
+
+	a, err := MakePublicKeySet(fqdn, map[string]any{"example": "A"})
+	require.NoError(t, err)
+	other, err := MakePublicKeySet(fqdn, map[string]any{"example": "B"})
+	require.NoError(t, err)
+	expired, err := MakePublicKeySet(fqdn, map[string]any{"exp": time.Now().Add(-time.Hour)})
+	require.NoError(t, err)
+	future, err := MakePublicKeySet(fqdn, map[string]any{"nbf": time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+
+	lines := func(s Set) []string {
+		record, err := s.resolver.LookupTXT(context.Background(), fqdn)
+		require.NoError(t, err)
+		return record
+	}
+
+	nxdomain := &net.DNSError{Err: "no such host", IsNotFound: true}
+	servfail := &net.DNSError{Err: "server misbehaving", IsTemporary: true}
+	timeout := &net.DNSError{Err: "i/o timeout", IsTimeout: true}
+
+	tests := []struct {
+		name      string
+		lines     []string
+		err       error
+		provider  Set
+		timeout   time.Duration
+		wantStage Stage
+		wantErrs  []error
+		notErrs   []error
+	}{
+		{
+			name:      "not found",
+			err:       nxdomain,
+			wantStage: StageLookup,
+			wantErrs:  []error{ErrNotFound, nxdomain},
+			notErrs:   []error{ErrInvalidJWT, ErrTimeout},
+		}, {
+			name:      "server failure",
+			err:       servfail,
+			wantStage: StageLookup,
+			wantErrs:  []error{ErrServerFailure, servfail},
+		}, {
+			name:      "timeout",
+			err:       timeout,
+			wantStage: StageLookup,
+			wantErrs:  []error{ErrTimeout, timeout},
+		}, {
+			name:      "deadline exceeded",
+			timeout:   10 * time.Millisecond,
+			wantStage: StageLookup,
+			wantErrs:  []error{ErrTimeout, context.DeadlineExceeded},
+		}, {
+			name:      "unknown lookup failure",
+			err:       errors.New("unknown"),
+			wantStage: StageLookup,
+			notErrs:   []error{ErrNotFound, ErrServerFailure, ErrTimeout},
+		}, {
+			name:      "empty record",
+			lines:     []string{},
+			wantStage: StageReassemble,
+			wantErrs:  []error{ErrEmptyRecord, ErrInvalidJWT},
+			notErrs:   []error{ErrMalformedLine},
+		}, {
+			name:      "only malformed lines",
+			lines:     []string{"header", "xx:payload"},
+			wantStage: StageReassemble,
+			wantErrs:  []error{ErrEmptyRecord, ErrMalformedLine, ErrInvalidJWT},
+		}, {
+			name:      "missing chunk",
+			lines:     []string{"00:header", "02:signature"},
+			wantStage: StageReassemble,
+			wantErrs:  []error{ErrMissingChunk, ErrInvalidJWT},
+			notErrs:   []error{ErrEmptyRecord, ErrDuplicateChunk},
+		}, {
+			name:      "duplicate and missing chunks",
+			lines:     []string{"00:header", "00:header", "02:signature"},
+			wantStage: StageReassemble,
+			wantErrs:  []error{ErrMissingChunk, ErrDuplicateChunk, ErrInvalidJWT},
+		}, {
+			name:      "expired",
+			lines:     lines(expired),
+			provider:  expired,
+			wantStage: StageVerify,
+			wantErrs:  []error{ErrExpired, ErrInvalidJWT},
+			notErrs:   []error{ErrBadSignature},
+		}, {
+			name:      "not yet valid",
+			lines:     lines(future),
+			provider:  future,
+			wantStage: StageVerify,
+			wantErrs:  []error{ErrNotYetValid, ErrInvalidJWT},
+		}, {
+			name:      "bad signature",
+			lines:     lines(a),
+			provider:  other,
+			wantStage: StageVerify,
+			wantErrs:  []error{ErrBadSignature, ErrInvalidJWT},
+			notErrs:   []error{ErrExpired},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := resolverFunc(func(ctx context.Context, _ string) ([]string, error) {
+				if tt.timeout > 0 {
+					<-ctx.Done()
+					return nil, ctx.Err()
+				}
+				return tt.lines, tt.err
+			})
+
+			provider := tt.provider
+			if provider.provider == nil {
+				provider = a
+			}
+
+			opts := []FetcherOption{
+				WithFQDN(fqdn),
+				WithResolver(resolver),
+				WithParseOptions(provider.provider),
+			}
+			if tt.timeout > 0 {
+				opts = append(opts, WithTimeout(tt.timeout))
+			}
+
+			fetcher, err := New(opts...)
+			require.NoError(t, err)
+
+			_, _, err = fetcher.Fetch(context.Background())
+
+			var fe *FetchError
+			require.ErrorAs(t, err, &fe)
+			assert.Equal(t, fqdn, fe.FQDN)
+			assert.Equal(t, tt.wantStage, fe.Stage)
+			assert.Contains(t, err.Error(), fqdn+": "+string(tt.wantStage))
+
+			for _, want := range tt.wantErrs {
+				assert.ErrorIs(t, err, want)
+			}
+			for _, notWant := range tt.notErrs {
+				assert.NotErrorIs(t, err, notWant)
+			}
+		})
+	}
+}
+
+func TestFetchErrorsFallback(t *testing.T) {
+	a, err := MakePublicKeySet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+
+	nxdomain := &net.DNSError{Err: "no such host", IsNotFound: true}
+
+	fetcher, err := New(
+		WithFQDNs("first.example.org", "second.example.org"),
+		WithResolver(resolverFunc(func(_ context.Context, name string) ([]string, error) {
+			if name == "first.example.org" {
+				return nil, nxdomain
+			}
+			return []string{"00:header", "02:signature"}, nil
+		})),
+		WithParseOptions(a.provider),
+	)
+	require.NoError(t, err)
+
+	_, _, err = fetcher.Fetch(context.Background())
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, err, ErrMissingChunk)
+
+	var joined interface{ Unwrap() []error }
+	require.ErrorAs(t, err, &joined)
+
+	errs := joined.Unwrap()
+	require.Len(t, errs, 2)
+
+	var fe *FetchError
+	require.ErrorAs(t, errs[0], &fe)
+	assert.Equal(t, "first.example.org", fe.FQDN)
+	assert.Equal(t, StageLookup, fe.Stage)
+
+	require.ErrorAs(t, errs[1], &fe)
+	assert.Equal(t, "second.example.org", fe.FQDN)
+	assert.Equal(t, StageReassemble, fe.Stage)
+
+	// Serving a stale token is only for lookup failures.
+	assert.False(t, lookupFailed(err))
+	assert.True(t, lookupFailed(errs[0]))
+}
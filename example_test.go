@@ -131,3 +131,23 @@ func Example() {
 	// Output:
 	// role: user
 }
+
+func ExampleParseRecord() {
+	// The lines of the record as they appear in the output of dig.
+	lines := []string{
+		`"01:.payload."`,
+		`"00:header"`,
+		`"02:signature"`,
+	}
+
+	txt, err := dnstxtjwt.ParseRecord(lines, dnstxtjwt.WithStrictReassembly())
+	fmt.Println(txt, err)
+
+	// A chunk is missing.
+	_, err = dnstxtjwt.ParseRecord(lines[1:], dnstxtjwt.WithStrictReassembly())
+	fmt.Println(err)
+
+	// Output:
+	// header.payload.signature <nil>
+	// index 1: missing chunk: no line has the index
+}
@@ -4,19 +4,32 @@
 package dnstxtjwt
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/lestrrat-go/jwx/v2/jws"
 	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/miekg/dns"
 )
 
 // Fetcher is the main structure for this package.  It holds the configuration
 // for the DNS TXT record to fetch and validate.
 type Fetcher struct {
-	// fqdn is the 'device_id.base_url' based on the input configuration.
-	fqdn string
+	// fqdns are the 'device_id.base_url' names based on the input
+	// configuration, in the order they are tried.
+	fqdns []string
+
+	// bases are the base domains used to build the names for FetchFor.
+	bases []string
+
+	// template is the template used to build the names for FetchFor.
+	template string
 
 	// resolver is used to supply the resolver to use
 	resolver Resolver
@@ -26,6 +39,40 @@ type Fetcher struct {
 
 	// opts is the list of options to use for JWT validation.
 	opts []jwt.ParseOption
+
+	// cache holds the last verified token if caching is enabled.
+	cache *cache
+
+	// stale holds the last verified token to serve if the lookup fails.
+	stale *cache
+
+	// retry is the policy for retrying failed lookups, if any.
+	retry *RetryPolicy
+
+	// dnssec is the policy for requiring authenticated answers, if any.
+	dnssec *dnssecPolicy
+
+	// observer receives the events of lookups and verifications.
+	observer observers
+
+	// log is the logging configuration.
+	log logConfig
+
+	// strict rejects records with any problem instead of reassembling them
+	// as well as possible.
+	strict bool
+
+	// binding requires a claim to match the name, if set.
+	binding *binding
+
+	// rollback rejects tokens older than the last one accepted, if set.
+	rollback *rollback
+
+	// clock is the source of the current time.
+	clock jwt.Clock
+
+	// inflight coalesces concurrent loads.
+	inflight *group
 }
 
 // Resolver is the interface that the DNS resolver must implement.
@@ -34,6 +81,37 @@ type Resolver interface {
 	LookupTXT(context.Context, string) ([]string, error)
 }
 
+// TXTRecordResolver is an optional interface a Resolver may implement to
+// provide the details of the response along with the TXT records.  If the
+// resolver implements it, the Fetcher uses it instead of LookupTXT.
+type TXTRecordResolver interface {
+	Resolver
+
+	// LookupTXTRecord returns the DNS TXT records for the given domain name
+	// along with the details of the response.
+	LookupTXTRecord(context.Context, string) (*TXTResult, error)
+}
+
+// TXTResult is the answer to a TXT query.
+type TXTResult struct {
+	// Lines are the TXT records, in the same form as LookupTXT returns them.
+	Lines []string
+
+	// TTL is the smallest TTL of the TXT records.  It is zero if unknown.
+	TTL time.Duration
+
+	// Rcode is the response code.  A failed response code is reported by an
+	// error wrapping a *RcodeError instead.
+	Rcode int
+
+	// Authenticated is the value of the AD (authenticated data) bit of the
+	// response.
+	Authenticated bool
+
+	// Msg is the full response, if available.
+	Msg *dns.Msg
+}
+
 // FetcherOption is the interface that all options must implement.
 type FetcherOption interface {
 	apply(*Fetcher) error
@@ -41,11 +119,16 @@ type FetcherOption interface {
 
 // New creates a new Record with the given options.
 func New(opts ...FetcherOption) (*Fetcher, error) {
-	var r Fetcher
+	r := Fetcher{
+		clock:    jwt.ClockFunc(time.Now),
+		inflight: &group{},
+	}
 
 	defaults := []FetcherOption{ // nolint:prealloc
 		WithResolver(nil),
 		WithTimeout(0),
+		WithNameTemplate(""),
+		WithLogger(nil),
 	}
 
 	vadors := []FetcherOption{ // nolint:prealloc
@@ -69,40 +152,456 @@ func New(opts ...FetcherOption) (*Fetcher, error) {
 // Fetch retrieves the DNS TXT record and validates it as a JWT based on the
 // options provided.  Options for validation should be set with the
 // WithParseOptions function.
+//
+// The failure to fetch from a name is reported as a *FetchError, which
+// matches the sentinel errors describing why it failed, such as ErrNotFound
+// or ErrExpired.
+//
+// If WithServeStale is used, a stale token and payload may be returned along
+// with an error that wraps ErrStale.
 func (r *Fetcher) Fetch(ctx context.Context) (jwt.Token, []byte, error) {
-	lines, err := r.fetch(ctx)
+	res, err := r.FetchResult(ctx)
+	if res == nil {
+		return nil, nil, err
+	}
+
+	return res.Token, res.Payload, err
+}
+
+// VerifyLines reassembles the JWT from the lines of a TXT record obtained out
+// of band, such as from a zone export, and verifies it the same way Fetch
+// does, including WithStrictReassembly and the options set with
+// WithParseOptions.  No lookup is made, and neither the cache nor the stale
+// token is used or updated.  There is no name, so neither the binding set by
+// WithFQDNBinding or WithDeviceIDBinding nor the version set by
+// WithAntiRollback is checked.  A failure is reported
+// as a *FetchError without an FQDN.
+func (r *Fetcher) VerifyLines(ctx context.Context, lines []string) (jwt.Token, []byte, error) {
+	res, err := r.verifyLines(ctx, "", lines, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return res.token, res.payload, nil
+}
+
+// VerifyToken is the same as VerifyLines, but for a JWT that is already
+// reassembled, such as one returned by ParseRecord.
+func (r *Fetcher) VerifyToken(ctx context.Context, raw string) (jwt.Token, []byte, error) {
+	res, err := r.verifyToken(ctx, "", raw, nil)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	txt := reassemble(lines)
+	return res.token, res.payload, nil
+}
+
+// Result is a verified token along with the FQDN it was found at.
+type Result struct {
+	// Token is the verified token.
+	Token jwt.Token
+
+	// Payload is the payload of the token as bytes.
+	Payload []byte
+
+	// FQDN is the name that the token was found at.
+	FQDN string
+}
+
+// FetchResult is the same as Fetch, but also reports which of the configured
+// FQDNs the token was found at.  The FQDNs are tried in order until one has
+// a record that verifies.  If none do, the error joins the failure of each.
+func (r *Fetcher) FetchResult(ctx context.Context) (*Result, error) {
+	if len(r.fqdns) == 0 {
+		return nil, fmt.Errorf("%w fqdn must be set", ErrInvalidInput)
+	}
+
+	return r.fetchNames(ctx, r.fqdns)
+}
+
+// FetchFor is the same as Fetch, but the names are built for the device ID
+// from the name template and each base domain set by WithBaseDomains.
+func (r *Fetcher) FetchFor(ctx context.Context, deviceID string) (jwt.Token, []byte, error) {
+	res, err := r.FetchResultFor(ctx, deviceID)
+	if res == nil {
+		return nil, nil, err
+	}
+
+	return res.Token, res.Payload, err
+}
+
+// FetchResultFor is the same as FetchResult, but the names are built for the
+// device ID from the name template and each base domain set by
+// WithBaseDomains.
+func (r *Fetcher) FetchResultFor(ctx context.Context, deviceID string) (*Result, error) {
+	names, err := r.namesFor(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.fetchNames(ctx, names)
+}
+
+// namesFor builds and validates the names for the device ID.
+func (r *Fetcher) namesFor(deviceID string) ([]string, error) {
+	if len(r.bases) == 0 {
+		return nil, fmt.Errorf("%w base domain must be set", ErrInvalidInput)
+	}
+	if deviceID == "" {
+		return nil, fmt.Errorf("%w device id must be set", ErrInvalidInput)
+	}
+
+	names := make([]string, 0, len(r.bases))
+	for _, base := range r.bases {
+		name, err := buildName(r.template, deviceID, base)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// fetchNames fetches the token from the first of the names that verifies,
+// using the cache and stale store if they are enabled.
+func (r *Fetcher) fetchNames(ctx context.Context, names []string) (*Result, error) {
+	key := strings.Join(names, " ")
+
+	if r.cache != nil {
+		if res, ok := r.cache.get(key, r.clock.Now(), r.validateOpts()); ok {
+			return res.export(), nil
+		}
+	}
+
+	res, err := r.load(ctx, names)
+	if err != nil {
+		return r.serveStale(key, err)
+	}
+
+	return res.export(), nil
+}
+
+// serveStale returns the last verified token along with an error that wraps
+// both the lookup failure and ErrStale, if serving stale tokens is enabled and
+// the failure was from the lookup.  Otherwise the error is returned as is.
+func (r *Fetcher) serveStale(key string, err error) (*Result, error) {
+	if r.stale == nil || !lookupFailed(err) {
+		return nil, err
+	}
+
+	res, ok := r.stale.get(key, r.clock.Now(), r.validateOpts())
+	if !ok {
+		return nil, err
+	}
+
+	return res.export(), errors.Join(err, ErrStale)
+}
+
+// result is a verified token along with the record it was assembled from.
+type result struct {
+	token   jwt.Token
+	payload []byte
+	raw     string
+	fqdn    string
+	ttl     time.Duration
+}
+
+// clone returns a deep copy of the result.
+func (res *result) clone() (*result, error) {
+	token, err := res.token.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	return &result{
+		token:   token,
+		payload: bytes.Clone(res.payload),
+		raw:     res.raw,
+		fqdn:    res.fqdn,
+		ttl:     res.ttl,
+	}, nil
+}
+
+func (res *result) export() *Result {
+	return &Result{
+		Token:   res.token,
+		Payload: res.payload,
+		FQDN:    res.fqdn,
+	}
+}
+
+// load performs the lookup and verification without consulting the cache.
+// Concurrent loads of the same names share the same lookup and verification.
+func (r *Fetcher) load(ctx context.Context, names []string) (*result, error) {
+	return r.inflight.do(ctx, strings.Join(names, " "),
+		func(ctx context.Context) (*result, error) {
+			return r.refresh(ctx, names)
+		})
+}
+
+// refresh tries each of the names in order until one verifies, storing the
+// result in the cache and stale store if they are enabled.  The error of each
+// name is a *FetchError; if more than one name failed, they are joined.
+func (r *Fetcher) refresh(ctx context.Context, names []string) (*result, error) {
+	errs := make([]error, 0, len(names))
+
+	for _, name := range names {
+		res, err := r.refreshName(ctx, name, nil)
+		if err == nil {
+			now := r.clock.Now()
+			key := strings.Join(names, " ")
+			if r.cache != nil {
+				r.cache.put(key, res, now)
+			}
+			if r.stale != nil {
+				r.stale.put(key, res, now)
+			}
+			return res, nil
+		}
+
+		errs = append(errs, err)
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if len(errs) == 1 {
+		return nil, errs[0]
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// refreshName performs the lookup and verification of a single name.  If
+// report is not nil, each stage is recorded in it.
+func (r *Fetcher) refreshName(ctx context.Context, name string, report *NameReport) (*result, error) {
+	logger := r.logger(name)
+
+	start := time.Now()
+	answer, err := r.fetch(ctx, name)
+	if err != nil {
+		logger.LogAttrs(ctx, slog.LevelDebug, "TXT lookup failed", slog.Any("error", err))
+
+		// An answer that isn't authenticated is not a lookup failure.
+		if errors.Is(err, ErrNotAuthenticated) {
+			report.stage(StageAuthenticate, start, err)
+			return nil, newFetchError(name, StageAuthenticate, err)
+		}
+		report.stage(StageLookup, start, err)
+		return nil, newFetchError(name, StageLookup, err, lookupKind(err))
+	}
+	report.stage(StageLookup, start, nil)
+
+	size := 0
+	for _, line := range answer.Lines {
+		size += len(line)
+	}
+	logger.LogAttrs(ctx, slog.LevelDebug, "received TXT record",
+		slog.Int("lines", len(answer.Lines)),
+		slog.Int("size", size),
+		slog.Duration("ttl", answer.TTL),
+		slog.Bool("authenticated", answer.Authenticated),
+	)
+
+	if r.dnssec != nil && r.dnssec.requireAD {
+		start = time.Now()
+		if !answer.Authenticated {
+			err := newFetchError(name, StageAuthenticate, errors.New("the AD bit is not set"), ErrNotAuthenticated)
+			report.stage(StageAuthenticate, start, err)
+			return nil, err
+		}
+		report.stage(StageAuthenticate, start, nil)
+	}
+
+	res, err := r.verifyLines(ctx, name, answer.Lines, report)
+	if err != nil {
+		return nil, err
+	}
+	res.ttl = answer.TTL
+
+	return res, nil
+}
+
+// logger returns the logger for the name, if there is one.
+func (r *Fetcher) logger(name string) *slog.Logger {
+	if name == "" {
+		return r.log.logger
+	}
+
+	return r.log.logger.With(slog.String("fqdn", name))
+}
+
+// verifyLines reassembles and verifies the JWT from the lines of the record
+// of the name, which is empty if the lines were obtained out of band.  If
+// report is not nil, each stage is recorded in it.
+func (r *Fetcher) verifyLines(ctx context.Context, name string, lines []string, report *NameReport) (*result, error) {
+	logger := r.logger(name)
+
+	start := time.Now()
+	txt, problems := reassembleLines(lines, r.strict)
+	report.describe(lines, txt, problems, r.strict)
+	for _, p := range problems {
+		attrs := []slog.Attr{
+			slog.String("problem", p.Kind.String()),
+			slog.String("reason", p.Reason),
+		}
+		if p.Index >= 0 {
+			attrs = append(attrs, slog.Int("index", p.Index))
+		}
+		if p.Line >= 0 {
+			attrs = append(attrs,
+				slog.Int("position", p.Line),
+				slog.Any("line", r.log.token(lines[p.Line])),
+			)
+		}
+		logger.LogAttrs(ctx, slog.LevelDebug, "problem reassembling TXT record", attrs...)
+	}
+	if len(problems) > 0 {
+		r.observer.OnReassembly(ReassemblyEvent{
+			FQDN:     name,
+			Lines:    len(lines),
+			Problems: problems,
+		})
+	}
+	if txt == "" {
+		err := reassemblyError(name, lines, problems)
+		logger.LogAttrs(ctx, slog.LevelDebug, "TXT record can't be reassembled", slog.Any("error", err))
+		report.stage(StageReassemble, start, err)
+		return nil, err
+	}
+	report.stage(StageReassemble, start, nil)
 
-	return r.verify(ctx, txt)
+	return r.verifyToken(ctx, name, txt, report)
 }
 
-func (r Fetcher) fetch(ctx context.Context) ([]string, error) {
+// verifyToken verifies the JWT from the record of the name, which is empty if
+// the JWT was obtained out of band.  If report is not nil, the stage is
+// recorded in it.
+func (r *Fetcher) verifyToken(ctx context.Context, name, txt string, report *NameReport) (*result, error) {
+	logger := r.logger(name)
+
+	start := time.Now()
+	token, payload, err := r.verify(ctx, txt)
+	if err == nil && name != "" {
+		// A diagnosis must not change the state of the protection.
+		err = r.checkName(ctx, name, token, report == nil)
+	}
+	report.stage(StageVerify, start, err)
+	if len(r.observer) > 0 {
+		r.observer.OnVerify(newVerifyEvent(name, txt, token, err))
+	}
+	if err != nil {
+		logger.LogAttrs(ctx, slog.LevelDebug, "JWT verification failed",
+			slog.Any("error", err),
+			slog.Any("token", r.log.token(txt)),
+		)
+		return nil, newFetchError(name, StageVerify, err, verifyKind(err))
+	}
+
+	logger.LogAttrs(ctx, slog.LevelDebug, "JWT verified",
+		slog.Any("token", r.log.token(txt)),
+	)
+
+	return &result{
+		token:   token,
+		payload: payload,
+		raw:     txt,
+		fqdn:    name,
+	}, nil
+}
+
+// checkName checks that the verified token is bound to the name and isn't
+// older than the highest version accepted for it, raising the highest version
+// if raise is set.
+func (r *Fetcher) checkName(ctx context.Context, name string, token jwt.Token, raise bool) error {
+	if r.binding != nil {
+		if err := r.binding.check(r, name, token); err != nil {
+			return err
+		}
+	}
+	if r.rollback != nil {
+		return r.rollback.check(ctx, r.logger(name), name, token, raise)
+	}
+
+	return nil
+}
+
+func (r Fetcher) fetch(ctx context.Context, name string) (*TXTResult, error) {
 	if r.timeout > 0 {
 		var cancel context.CancelFunc
-		// Don't wait forever if things are broken.
+		// Don't wait forever if things are broken.  The timeout is shared by
+		// all of the attempts.
 		ctx, cancel = context.WithTimeout(ctx, r.timeout)
 		defer cancel()
 	}
 
-	txtChan := make(chan []string)
-	errChan := make(chan error)
+	var attempt int
+	lookup := func(ctx context.Context) (*TXTResult, error) {
+		attempt++
+		return r.observedLookup(ctx, name, attempt)
+	}
+
+	if r.retry == nil {
+		return lookup(ctx)
+	}
+
+	return r.retry.do(ctx, lookup)
+}
+
+// observedLookup performs a single TXT lookup, sending the events to the
+// observers.
+func (r Fetcher) observedLookup(ctx context.Context, name string, attempt int) (*TXTResult, error) {
+	if len(r.observer) == 0 {
+		return r.lookup(ctx, name)
+	}
+
+	r.observer.OnLookupStart(LookupStartEvent{
+		FQDN:    name,
+		Attempt: attempt,
+	})
+
+	start := time.Now()
+	answer, err := r.lookup(ctx, name)
+
+	e := LookupDoneEvent{
+		FQDN:    name,
+		Attempt: attempt,
+		Latency: time.Since(start),
+		Err:     err,
+	}
+	if answer != nil {
+		e.Lines = len(answer.Lines)
+		for _, line := range answer.Lines {
+			e.Bytes += len(line)
+		}
+	}
+	r.observer.OnLookupDone(e)
+
+	return answer, err
+}
+
+// lookup performs a single TXT lookup, giving up when the context is done
+// even if the resolver doesn't.
+func (r Fetcher) lookup(ctx context.Context, name string) (*TXTResult, error) {
+	// The channels are buffered so the goroutine can always exit, even if
+	// nobody is waiting for the answer anymore.
+	txtChan := make(chan *TXTResult, 1)
+	errChan := make(chan error, 1)
 
 	go func() {
-		lines, err := r.resolver.LookupTXT(ctx, r.fqdn)
+		answer, err := r.resolve(ctx, name)
 		if err != nil {
 			errChan <- err
 			return
 		}
-		txtChan <- lines
+		txtChan <- answer
 	}()
 
 	select {
-	case lines := <-txtChan:
-		return lines, nil
+	case answer := <-txtChan:
+		return answer, nil
 	case err := <-errChan:
 		return nil, err
 	case <-ctx.Done():
@@ -110,24 +609,80 @@ func (r Fetcher) fetch(ctx context.Context) ([]string, error) {
 	}
 }
 
+// resolve performs the TXT lookup using the resolver, validating the DNSSEC
+// chain of trust locally if trust anchors are set.
+func (r Fetcher) resolve(ctx context.Context, name string) (*TXTResult, error) {
+	if r.dnssec == nil || len(r.dnssec.anchors) == 0 {
+		return lookupRecord(ctx, r.resolver, name)
+	}
+
+	exchanger, ok := r.resolver.(Exchanger)
+	if !ok {
+		return nil, fmt.Errorf("%w: the resolver can't send DNSSEC queries", ErrNotAuthenticated)
+	}
+
+	v := validator{
+		exchanger: exchanger,
+		anchors:   r.dnssec.anchors,
+		now:       r.clock.Now(),
+	}
+
+	return v.lookupTXT(ctx, name)
+}
+
+// lookupRecord looks up the name with the resolver, including the details of
+// the response if the resolver implements TXTRecordResolver.
+func lookupRecord(ctx context.Context, resolver Resolver, name string) (*TXTResult, error) {
+	if rr, ok := resolver.(TXTRecordResolver); ok {
+		return rr.LookupTXTRecord(ctx, name)
+	}
+
+	lines, err := resolver.LookupTXT(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TXTResult{Lines: lines}, nil
+}
+
+// reassemblyError describes why the record couldn't be reassembled.
+func reassemblyError(name string, lines []string, problems []ReassemblyProblem) *FetchError {
+	return newFetchError(name, StageReassemble, newRecordError(lines, problems), ErrInvalidJWT)
+}
+
 // verify is a helper function to verify the JWT and return the token with the
 // payload as bytes.
 func (r *Fetcher) verify(ctx context.Context, txt string) (jwt.Token, []byte, error) {
 	input := []byte(txt)
 
-	opts := append(r.opts, jwt.WithContext(ctx))
+	// Clip the options so the append never writes to the shared slice, as
+	// verifications run concurrently.
+	opts := append(slices.Clip(r.opts), jwt.WithContext(ctx))
 
 	token, err := jwt.Parse(input, opts...)
 	if err != nil {
-		return nil, nil, errors.Join(err, ErrInvalidJWT)
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidJWT, err)
 	}
 
 	// Now get the payload as bytes for the return value
 	msg, err := jws.Parse(input)
 	if err != nil {
 		// I don't think this can happen, but just in case.
-		return nil, nil, errors.Join(err, ErrInvalidJWT)
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidJWT, err)
 	}
 
 	return token, msg.Payload(), nil
 }
+
+// validateOpts returns the subset of the parse options that apply to
+// validating the claims of an already parsed token.
+func (r *Fetcher) validateOpts() []jwt.ValidateOption {
+	opts := make([]jwt.ValidateOption, 0, len(r.opts))
+	for _, opt := range r.opts {
+		if vo, ok := opt.(jwt.ValidateOption); ok {
+			opts = append(opts, vo)
+		}
+	}
+
+	return opts
+}
@@ -4,11 +4,15 @@
 package dnstxtjwt
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/miekg/dns"
 )
 
 type fetcherOptionFunc func(*Fetcher) error
@@ -31,11 +35,124 @@ func WithResolver(resolver Resolver) FetcherOption {
 	)
 }
 
+// WithRacingResolvers sets the resolver to race the lookup across each of the
+// resolvers, starting them stagger apart.  Only an answer that reassembles and
+// verifies based on the parse options of the Fetcher, and passes the checks of
+// WithFQDNBinding, WithDeviceIDBinding and WithAntiRollback if they are used,
+// wins, so a fast resolver with a broken or old record can't beat a slower one
+// with a valid record.
+func WithRacingResolvers(stagger time.Duration, resolvers ...Resolver) FetcherOption {
+	return fetcherOptionFunc(
+		func(r *Fetcher) error {
+			if len(resolvers) == 0 {
+				return fmt.Errorf("%w at least one resolver must be set", ErrInvalidInput)
+			}
+			accept := func(ctx context.Context, name string, lines []string) error {
+				txt, _ := reassembleLines(lines, r.strict)
+				token, _, err := r.verify(ctx, txt)
+				if err != nil {
+					return err
+				}
+				// The version is only raised once the winner is verified again.
+				return r.checkName(ctx, name, token, false)
+			}
+			r.resolver = RaceResolvers(stagger, accept, resolvers...)
+			return nil
+		},
+	)
+}
+
+// WithQuorum sets the resolver to query each of the resolvers and only accept
+// a record when at least m of them return byte-identical JWTs.  This guards
+// against a single poisoned resolver serving an old, but validly signed,
+// token.  If the quorum is not reached, Fetch returns a *QuorumError.
+func WithQuorum(m int, resolvers ...Resolver) FetcherOption {
+	return fetcherOptionFunc(
+		func(r *Fetcher) error {
+			resolver, err := QuorumResolvers(m, resolvers...)
+			if err != nil {
+				return err
+			}
+			r.resolver = resolver
+			return nil
+		},
+	)
+}
+
+// WithNameservers sets the resolver to send queries directly to the
+// nameservers, such as the authoritative servers of the zone, bypassing any
+// caching resolvers.  The nameservers and options are the same as for
+// NewDNSResolver: queries go over UDP with a fallback to TCP when the response
+// is truncated, and the nameservers are tried in order unless WithRoundRobin
+// is set.
+func WithNameservers(servers []string, opts ...DNSOption) FetcherOption {
+	return fetcherOptionFunc(
+		func(r *Fetcher) error {
+			resolver, err := NewDNSResolver(servers, opts...)
+			if err != nil {
+				return err
+			}
+			r.resolver = resolver
+			return nil
+		},
+	)
+}
+
 // WithFQDN sets the FQDN to use for DNS queries.
 func WithFQDN(fqdn string) FetcherOption {
+	return WithFQDNs(fqdn)
+}
+
+// WithFQDNs sets an ordered list of FQDNs to use for DNS queries.  Each FQDN
+// is tried in turn until one has a record that verifies.
+func WithFQDNs(fqdns ...string) FetcherOption {
+	return fetcherOptionFunc(
+		func(r *Fetcher) error {
+			r.fqdns = slices.Clone(fqdns)
+			return nil
+		},
+	)
+}
+
+// WithBaseDomain sets the base domain used to build the names for FetchFor.
+func WithBaseDomain(domain string) FetcherOption {
+	return WithBaseDomains(domain)
+}
+
+// WithBaseDomains sets an ordered list of base domains used to build the names
+// for FetchFor.  Each name is tried in turn until one has a record that
+// verifies.
+func WithBaseDomains(domains ...string) FetcherOption {
+	return fetcherOptionFunc(
+		func(r *Fetcher) error {
+			r.bases = make([]string, 0, len(domains))
+			for _, domain := range domains {
+				base, err := normalizeName(domain)
+				if err != nil {
+					return err
+				}
+				r.bases = append(r.bases, base)
+			}
+			return nil
+		},
+	)
+}
+
+// WithNameTemplate sets the template used to build the names for FetchFor.
+// The template must contain DeviceIDPlaceholder and may contain
+// BaseDomainPlaceholder, for example "{device_id}._jwt.{base}".  The device ID
+// must be a single label.  An empty template sets the default of
+// DefaultNameTemplate.
+func WithNameTemplate(tmpl string) FetcherOption {
 	return fetcherOptionFunc(
 		func(r *Fetcher) error {
-			r.fqdn = fqdn
+			if tmpl == "" {
+				tmpl = DefaultNameTemplate
+			}
+			if !strings.Contains(tmpl, DeviceIDPlaceholder) {
+				return fmt.Errorf("%w name template must contain %s", ErrInvalidInput, DeviceIDPlaceholder)
+			}
+			r.template = tmpl
 			return nil
 		},
 	)
@@ -66,11 +183,199 @@ func WithParseOptions(opts ...jwt.ParseOption) FetcherOption {
 	)
 }
 
+// WithClock sets the source of the current time used to validate the JWT,
+// expire cached tokens and check DNSSEC signatures.  It is mostly useful in
+// tests.  If the clock is nil, time.Now is used.
+func WithClock(clock jwt.Clock) FetcherOption {
+	return fetcherOptionFunc(
+		func(r *Fetcher) error {
+			if clock == nil {
+				clock = jwt.ClockFunc(time.Now)
+			}
+			r.clock = clock
+			r.opts = append(r.opts, jwt.WithClock(clock))
+			return nil
+		},
+	)
+}
+
+// WithFQDNBinding requires the claim of the token, such as "sub", "aud" or a
+// custom "fqdn" claim, to match the name the token was fetched from.  This
+// stops a validly signed token for one device from being accepted when it is
+// copied into the record of another.  The claim may be a string or a list of
+// strings, and matches if any of them is the name, ignoring case and any
+// trailing dot.  A token that doesn't match fails verification with an error
+// that matches ErrNameMismatch.  Tokens verified with VerifyLines or
+// VerifyToken have no name, so the binding isn't checked for them.
+func WithFQDNBinding(claim string) FetcherOption {
+	return withBinding(claim, false)
+}
+
+// WithDeviceIDBinding is the same as WithFQDNBinding, but the claim must match
+// the device ID in the name instead.  The device ID is extracted from the name
+// using the name template, set by WithNameTemplate, and the base domains, set
+// by WithBaseDomains, if any.  With the default template, the device ID is the
+// first label of the name.
+func WithDeviceIDBinding(claim string) FetcherOption {
+	return withBinding(claim, true)
+}
+
+func withBinding(claim string, deviceID bool) FetcherOption {
+	return fetcherOptionFunc(
+		func(r *Fetcher) error {
+			if claim == "" {
+				return fmt.Errorf("%w binding claim must be set", ErrInvalidInput)
+			}
+			r.binding = &binding{
+				claim:    claim,
+				deviceID: deviceID,
+			}
+			return nil
+		},
+	)
+}
+
+// WithAntiRollback rejects a token whose version is lower than the highest
+// version accepted for the same FQDN, so an attacker or a lagging secondary
+// nameserver can't serve an older token that hasn't expired yet.  The version
+// is the integer claim, or the iat claim if claim is empty.  A token without
+// the claim fails verification, and an older token fails with an error that
+// matches ErrRollback.  A token with the same version is accepted.
+//
+// The highest versions are kept in the store, such as a FileVersionStore, so
+// the protection survives restarts.  If the store is nil, they are only kept
+// in memory.  Diagnose checks the version, but never raises the highest
+// version accepted.
+func WithAntiRollback(store VersionStore, claim string) FetcherOption {
+	return fetcherOptionFunc(
+		func(r *Fetcher) error {
+			if store == nil {
+				store = &memoryVersionStore{}
+			}
+			if claim == "" {
+				claim = jwt.IssuedAtKey
+			}
+			r.rollback = &rollback{
+				claim: claim,
+				store: store,
+			}
+			return nil
+		},
+	)
+}
+
+// WithObserver adds an observer that receives the events of the lookups and
+// verifications.  This option may be used more than once, and each observer
+// receives every event.  A nil observer is ignored.
+func WithObserver(o Observer) FetcherOption {
+	return fetcherOptionFunc(
+		func(r *Fetcher) error {
+			if o != nil {
+				r.observer = append(r.observer, o)
+			}
+			return nil
+		},
+	)
+}
+
+// WithCache enables caching of the last verified token.  A cached token is
+// served until the earliest of maxAge, the TTL of the record, or the token's
+// exp claim, and the time based claims are re-validated on every hit.  The TTL
+// is only known if the resolver implements TXTRecordResolver.  A maxAge of
+// zero or less caches the token until the TTL or exp claim expire.
+func WithCache(maxAge time.Duration) FetcherOption {
+	return fetcherOptionFunc(
+		func(r *Fetcher) error {
+			r.cache = newCache(maxAge, true)
+			return nil
+		},
+	)
+}
+
+// WithServeStale enables serving the last verified token when the DNS lookup
+// fails.  The stale token is served for up to window after it was verified,
+// but never after its exp claim.  When a stale token is served, Fetch returns
+// the token and payload along with an error wrapping both the lookup failure
+// and ErrStale.  A window of zero or less serves the token until it expires.
+// The TTL of the record doesn't limit the window.
+func WithServeStale(window time.Duration) FetcherOption {
+	return fetcherOptionFunc(
+		func(r *Fetcher) error {
+			r.stale = newCache(window, false)
+			return nil
+		},
+	)
+}
+
+// WithRetry sets the policy for retrying failed DNS lookups.  All of the
+// attempts share the deadline set by WithTimeout.  By default, only one
+// lookup is made.
+func WithRetry(policy RetryPolicy) FetcherOption {
+	return fetcherOptionFunc(
+		func(r *Fetcher) error {
+			p, err := policy.normalize()
+			if err != nil {
+				return fmt.Errorf("%w %w", ErrInvalidInput, err)
+			}
+			r.retry = p
+			return nil
+		},
+	)
+}
+
+// WithRequireAD rejects answers that don't have the AD (authenticated data)
+// bit set by the resolver.  Only use this with a validating resolver that is
+// trusted and reached over a secure channel, such as DNS-over-TLS or
+// DNS-over-HTTPS, since the bit is otherwise easily forged.  The resolver must
+// implement TXTRecordResolver, otherwise every answer is rejected.  Rejected
+// answers return an error wrapping ErrNotAuthenticated.
+func WithRequireAD() FetcherOption {
+	return fetcherOptionFunc(
+		func(r *Fetcher) error {
+			if r.dnssec == nil {
+				r.dnssec = &dnssecPolicy{}
+			}
+			r.dnssec.requireAD = true
+			return nil
+		},
+	)
+}
+
+// WithDNSSECTrustAnchors validates the RRSIG and DNSKEY chain of trust of each
+// answer locally, from the trust anchors down to the TXT records.  The trust
+// anchors are DS or DNSKEY records for the zones that are trusted, for
+// example the root zone or the zone the records are published in.  The
+// resolver must implement Exchanger.  Answers that fail validation return an
+// error wrapping ErrNotAuthenticated.
+func WithDNSSECTrustAnchors(anchors ...dns.RR) FetcherOption {
+	return fetcherOptionFunc(
+		func(r *Fetcher) error {
+			if len(anchors) == 0 {
+				return fmt.Errorf("%w at least one trust anchor must be set", ErrInvalidInput)
+			}
+			m, err := newAnchors(anchors)
+			if err != nil {
+				return err
+			}
+			if r.dnssec == nil {
+				r.dnssec = &dnssecPolicy{}
+			}
+			r.dnssec.anchors = m
+			return nil
+		},
+	)
+}
+
 func validateOptions() FetcherOption {
 	return fetcherOptionFunc(
 		func(r *Fetcher) error {
-			if r.fqdn == "" {
-				return fmt.Errorf("%w fqdn must be set", ErrInvalidInput)
+			if len(r.fqdns) == 0 && len(r.bases) == 0 {
+				return fmt.Errorf("%w fqdn or base domain must be set", ErrInvalidInput)
+			}
+			for _, fqdn := range r.fqdns {
+				if fqdn == "" {
+					return fmt.Errorf("%w fqdn must be set", ErrInvalidInput)
+				}
 			}
 			return nil
 		},
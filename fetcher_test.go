@@ -355,3 +355,81 @@ type resolverFunc func(context.Context, string) ([]string, error)
 func (f resolverFunc) LookupTXT(ctx context.Context, name string) ([]string, error) {
 	return f(ctx, name)
 }
+
+func TestFetchResultFallback(t *testing.T) {
+	a, err := MakeTrustedSet("primary.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+
+	b, err := MakeTrustedSet("primary.example.org", map[string]any{"example": "B"})
+	require.NoError(t, err)
+
+	// zones serves the record of a under both names, and the record of b,
+	// which isn't trusted, under bad.example.org.
+	zones := resolverFunc(func(ctx context.Context, name string) ([]string, error) {
+		switch name {
+		case "primary.example.org", "dr.example.org":
+			return a.resolver.LookupTXT(ctx, "primary.example.org")
+		case "bad.example.org":
+			return b.resolver.LookupTXT(ctx, "primary.example.org")
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	})
+
+	tests := []struct {
+		name     string
+		fqdns    []string
+		wantFQDN string
+		wantErrs []string
+	}{
+		{
+			name:     "the first name works",
+			fqdns:    []string{"primary.example.org", "dr.example.org"},
+			wantFQDN: "primary.example.org",
+		}, {
+			name:     "the first name is missing",
+			fqdns:    []string{"missing.example.org", "dr.example.org"},
+			wantFQDN: "dr.example.org",
+		}, {
+			name:     "the first name doesn't verify",
+			fqdns:    []string{"bad.example.org", "dr.example.org"},
+			wantFQDN: "dr.example.org",
+		}, {
+			name:     "no names work",
+			fqdns:    []string{"missing.example.org", "bad.example.org"},
+			wantErrs: []string{"missing.example.org", "bad.example.org"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher, err := New(
+				WithFQDNs(tt.fqdns...),
+				WithResolver(zones),
+				WithParseOptions(a.provider),
+			)
+			require.NoError(t, err)
+
+			res, err := fetcher.FetchResult(context.Background())
+			if len(tt.wantErrs) > 0 {
+				assert.Nil(t, res)
+				require.Error(t, err)
+				for _, want := range tt.wantErrs {
+					assert.Contains(t, err.Error(), want)
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, res)
+			assert.Equal(t, tt.wantFQDN, res.FQDN)
+			assert.Equal(t, a.payload, res.Payload)
+			assert.NotNil(t, res.Token)
+		})
+	}
+}
+
+func TestWithFQDNsInvalid(t *testing.T) {
+	fetcher, err := New(WithFQDNs("primary.example.org", ""))
+	assert.Nil(t, fetcher)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
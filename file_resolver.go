@@ -0,0 +1,258 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+// jwtFileExt is the optional extension of the JWT files in a directory.
+const jwtFileExt = ".jwt"
+
+// FileResolver is a Resolver that answers from local files instead of DNS,
+// for environments without DNS.  The files are checked on each lookup and
+// reloaded when they change.
+type FileResolver struct {
+	path       string
+	origin     string
+	createOpts []CreateOption
+
+	// stamp describes the files, so changes are detected.
+	stamp func() (string, error)
+
+	// load reads the records from the files by canonical name.
+	load func() (map[string][]string, error)
+
+	mu      sync.Mutex
+	loaded  string
+	records map[string][]string
+}
+
+// FileOption is the interface that all FileResolver options must implement.
+type FileOption interface {
+	apply(*FileResolver) error
+}
+
+// NewZoneFileResolver creates a new FileResolver that answers from the TXT
+// records of a BIND-style zone file.
+func NewZoneFileResolver(path string, opts ...FileOption) (*FileResolver, error) {
+	f, err := newFileResolver(path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	f.stamp = f.fileStamp
+	f.load = f.loadZone
+
+	return f, f.refresh()
+}
+
+// NewDirResolver creates a new FileResolver that answers from a directory
+// containing one JWT file per FQDN.  The name of each file is the FQDN, with
+// an optional ".jwt" extension, for example "device.example.org.jwt".  The JWT
+// is split into a record with CreateRecord.  Hidden files and directories are
+// ignored.
+func NewDirResolver(dir string, opts ...FileOption) (*FileResolver, error) {
+	f, err := newFileResolver(dir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	f.stamp = f.dirStamp
+	f.load = f.loadDir
+
+	return f, f.refresh()
+}
+
+func newFileResolver(path string, opts []FileOption) (*FileResolver, error) {
+	if path == "" {
+		return nil, fmt.Errorf("%w path must be set", ErrInvalidInput)
+	}
+
+	f := FileResolver{
+		path: path,
+	}
+
+	defaults := []FileOption{ // nolint:prealloc
+		WithOrigin(""),
+	}
+
+	opts = append(defaults, opts...)
+
+	for _, opt := range opts {
+		if opt != nil {
+			if err := opt.apply(&f); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &f, nil
+}
+
+// LookupTXT returns the TXT records for the given domain name from the files.
+func (f *FileResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.refreshLocked(); err != nil {
+		return nil, &net.DNSError{
+			UnwrapErr: err,
+			Err:       err.Error(),
+			Name:      name,
+			Server:    f.path,
+		}
+	}
+
+	lines := f.records[dns.CanonicalName(name)]
+	if len(lines) == 0 {
+		return nil, &net.DNSError{
+			Err:        "no such host",
+			Name:       name,
+			Server:     f.path,
+			IsNotFound: true,
+		}
+	}
+
+	return slices.Clone(lines), nil
+}
+
+func (f *FileResolver) refresh() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.refreshLocked()
+}
+
+// refreshLocked reloads the records if the files changed.  If the reload
+// fails, it is tried again on the next lookup.
+func (f *FileResolver) refreshLocked() error {
+	stamp, err := f.stamp()
+	if err != nil {
+		return err
+	}
+	if f.records != nil && stamp == f.loaded {
+		return nil
+	}
+
+	records, err := f.load()
+	if err != nil {
+		return err
+	}
+
+	f.records = records
+	f.loaded = stamp
+
+	return nil
+}
+
+// fileStamp describes the zone file by its size and modification time.
+func (f *FileResolver) fileStamp() (string, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%d/%d", info.Size(), info.ModTime().UnixNano()), nil
+}
+
+// dirStamp describes the directory by the names, sizes and modification times
+// of the files.
+func (f *FileResolver) dirStamp() (string, error) {
+	entries, err := os.ReadDir(f.path)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, entry := range entries {
+		if !isJWTFile(entry) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s/%d/%d\n", entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+
+	return b.String(), nil
+}
+
+// loadZone reads the TXT records of the zone file.
+func (f *FileResolver) loadZone() (map[string][]string, error) {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	records := make(map[string][]string)
+
+	zp := dns.NewZoneParser(file, f.origin, f.path)
+	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
+		txt, isTXT := rr.(*dns.TXT)
+		if !isTXT {
+			continue
+		}
+
+		name := dns.CanonicalName(txt.Hdr.Name)
+		records[name] = append(records[name], strings.Join(txt.Txt, ""))
+	}
+	if err := zp.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// loadDir reads the JWT files of the directory and splits them into records.
+func (f *FileResolver) loadDir() (map[string][]string, error) {
+	entries, err := os.ReadDir(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make(map[string][]string, len(entries))
+
+	for _, entry := range entries {
+		if !isJWTFile(entry) {
+			continue
+		}
+
+		buf, err := os.ReadFile(filepath.Join(f.path, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		token := strings.TrimSpace(string(buf))
+		if token == "" {
+			continue
+		}
+
+		lines, err := CreateRecord(token, f.createOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+
+		name := dns.CanonicalName(strings.TrimSuffix(entry.Name(), jwtFileExt))
+		records[name] = lines
+	}
+
+	return records, nil
+}
+
+// isJWTFile reports if the directory entry may hold a JWT.
+func isJWTFile(entry os.DirEntry) bool {
+	return entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".")
+}
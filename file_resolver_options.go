@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"fmt"
+
+	"github.com/miekg/dns"
+)
+
+type fileOptionFunc func(*FileResolver) error
+
+func (f fileOptionFunc) apply(r *FileResolver) error {
+	return f(r)
+}
+
+// WithOrigin sets the origin used for relative names in a zone file that
+// doesn't set $ORIGIN.  An empty value sets the default of the root zone.
+func WithOrigin(origin string) FileOption {
+	return fileOptionFunc(
+		func(f *FileResolver) error {
+			if origin == "" {
+				origin = "."
+			}
+			if _, ok := dns.IsDomainName(origin); !ok {
+				return fmt.Errorf("%w invalid origin %q", ErrInvalidInput, origin)
+			}
+			f.origin = dns.Fqdn(origin)
+			return nil
+		},
+	)
+}
+
+// WithCreateOptions sets the options used to split the JWT files of a
+// directory into records.
+func WithCreateOptions(opts ...CreateOption) FileOption {
+	return fileOptionFunc(
+		func(f *FileResolver) error {
+			f.createOpts = append(f.createOpts, opts...)
+			return nil
+		},
+	)
+}
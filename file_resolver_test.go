@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile writes the file and moves its modification time forward, so the
+// change is seen even on file systems with a coarse time resolution.
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	mtime := time.Now().Add(time.Duration(len(content)) * time.Second)
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+}
+
+// zoneFile creates a zone file with the TXT records by relative name.
+func zoneFile(records map[string][]string) string {
+	var b strings.Builder
+
+	b.WriteString("$ORIGIN example.org.\n$TTL 300\n")
+	b.WriteString("@ IN A 10.0.0.1\n")
+	for name, lines := range records {
+		for _, line := range lines {
+			fmt.Fprintf(&b, "%s IN TXT %q\n", name, line)
+		}
+	}
+
+	return b.String()
+}
+
+func TestZoneFileResolver(t *testing.T) {
+	a, err := MakeTrustedSet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+	b, err := MakeTrustedSet("fqdn.example.org", map[string]any{"example": "B"})
+	require.NoError(t, err)
+
+	recordA, err := a.resolver.LookupTXT(context.Background(), a.fqdn)
+	require.NoError(t, err)
+	recordB, err := b.resolver.LookupTXT(context.Background(), b.fqdn)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "example.org.zone")
+	writeFile(t, path, zoneFile(map[string][]string{"fqdn": recordA}))
+
+	resolver, err := NewZoneFileResolver(path)
+	require.NoError(t, err)
+
+	fetcher, err := New(
+		WithFQDN(a.fqdn),
+		WithResolver(resolver),
+		WithParseOptions(a.provider),
+	)
+	require.NoError(t, err)
+
+	_, buf, err := fetcher.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, a.payload, buf)
+
+	_, err = resolver.LookupTXT(context.Background(), "missing.example.org")
+	var dnsErr *net.DNSError
+	require.ErrorAs(t, err, &dnsErr)
+	assert.True(t, dnsErr.IsNotFound)
+
+	// The record is reloaded when the file changes.
+	writeFile(t, path, zoneFile(map[string][]string{"fqdn": recordB}))
+
+	lines, err := resolver.LookupTXT(context.Background(), "FQDN.example.org.")
+	require.NoError(t, err)
+	assert.Equal(t, recordB, lines)
+
+	// A broken file is reported, and the lookup works once it is fixed.
+	writeFile(t, path, "fqdn IN BOGUS 1\n")
+
+	_, err = resolver.LookupTXT(context.Background(), "fqdn.example.org")
+	require.ErrorAs(t, err, &dnsErr)
+	assert.False(t, dnsErr.IsNotFound)
+
+	writeFile(t, path, zoneFile(map[string][]string{"fqdn": recordA}))
+
+	lines, err = resolver.LookupTXT(context.Background(), "fqdn.example.org")
+	require.NoError(t, err)
+	assert.Equal(t, recordA, lines)
+}
+
+func TestDirResolver(t *testing.T) {
+	a, err := MakeTrustedSet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+	b, err := MakeTrustedSet("other.example.org", map[string]any{"example": "B"})
+	require.NoError(t, err)
+
+	recordA, err := a.resolver.LookupTXT(context.Background(), a.fqdn)
+	require.NoError(t, err)
+	recordB, err := b.resolver.LookupTXT(context.Background(), b.fqdn)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "fqdn.example.org.jwt"), reassemble(recordA)+"\n")
+	writeFile(t, filepath.Join(dir, ".hidden.example.org"), reassemble(recordB))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub.example.org"), 0o700))
+
+	resolver, err := NewDirResolver(dir, WithCreateOptions(WithMaxLineLength(100)))
+	require.NoError(t, err)
+
+	fetcher, err := New(
+		WithFQDNs(b.fqdn, a.fqdn),
+		WithResolver(resolver),
+		WithParseOptions(a.provider),
+	)
+	require.NoError(t, err)
+
+	res, err := fetcher.FetchResult(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, a.payload, res.Payload)
+	assert.Equal(t, a.fqdn, res.FQDN)
+
+	lines, err := resolver.LookupTXT(context.Background(), a.fqdn)
+	require.NoError(t, err)
+	for _, line := range lines {
+		assert.LessOrEqual(t, len(line), 100)
+	}
+
+	for _, name := range []string{".hidden.example.org", "sub.example.org", b.fqdn} {
+		_, err = resolver.LookupTXT(context.Background(), name)
+		var dnsErr *net.DNSError
+		require.ErrorAs(t, err, &dnsErr)
+		assert.True(t, dnsErr.IsNotFound)
+	}
+
+	// A new file is picked up.
+	writeFile(t, filepath.Join(dir, b.fqdn), reassemble(recordB))
+
+	lines, err = resolver.LookupTXT(context.Background(), b.fqdn)
+	require.NoError(t, err)
+	assert.Equal(t, reassemble(recordB), reassemble(lines))
+}
+
+func TestNewFileResolverInvalid(t *testing.T) {
+	_, err := NewZoneFileResolver("")
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	_, err = NewDirResolver("")
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	_, err = NewZoneFileResolver(filepath.Join(t.TempDir(), "missing.zone"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = NewZoneFileResolver("example.zone", WithOrigin("bad..origin"))
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
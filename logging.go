@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// LoggerOption is an option that applies to both a Fetcher and CreateRecord.
+type LoggerOption interface {
+	FetcherOption
+	CreateOption
+}
+
+// logConfig is the logging configuration shared by Fetcher and CreateRecord.
+type logConfig struct {
+	logger *slog.Logger
+
+	// tokens logs the tokens and lines of records instead of redacting them.
+	tokens bool
+}
+
+// token returns the token, or part of one, as a value that is redacted in the
+// logs unless WithTokenLogging is used.
+func (l logConfig) token(s string) slog.LogValuer {
+	return secret{value: s, show: l.tokens}
+}
+
+type loggerOptionFunc func(*logConfig)
+
+func (f loggerOptionFunc) apply(r *Fetcher) error {
+	f(&r.log)
+	return nil
+}
+
+func (f loggerOptionFunc) applyCreate(c *create) {
+	f(&c.log)
+}
+
+// WithLogger sets the logger.  Details of the records, such as the lines
+// skipped while reassembling a record and why, the missing indexes, the size
+// of the record and the reason verification failed are logged at the debug
+// level.  The contents of tokens are redacted unless WithTokenLogging is used.
+// If the logger is nil or this option is unset, nothing is logged.
+func WithLogger(logger *slog.Logger) LoggerOption {
+	return loggerOptionFunc(
+		func(l *logConfig) {
+			if logger == nil {
+				logger = slog.New(slog.DiscardHandler)
+			}
+			l.logger = logger
+		},
+	)
+}
+
+// WithTokenLogging logs the contents of the tokens and the lines of the
+// records instead of redacting them.  Only use it for debugging, as the logs
+// then hold the tokens.
+func WithTokenLogging() LoggerOption {
+	return loggerOptionFunc(
+		func(l *logConfig) {
+			l.tokens = true
+		},
+	)
+}
+
+// secret is a value that is redacted in the logs unless show is set.
+type secret struct {
+	value string
+	show  bool
+}
+
+// LogValue implements slog.LogValuer.
+func (s secret) LogValue() slog.Value {
+	if s.show {
+		return slog.StringValue(s.value)
+	}
+
+	return slog.StringValue(fmt.Sprintf("REDACTED(%d bytes)", len(s.value)))
+}
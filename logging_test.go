@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logEntries decodes the JSON log entries.
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func newTestLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func TestFetcherLogging(t *testing.T) {
+	a, err := MakeTrustedSet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+
+	record, err := a.resolver.LookupTXT(context.Background(), a.fqdn)
+	require.NoError(t, err)
+
+	// A malformed line is skipped, and a missing index breaks the record.
+	broken := append([]string{"malformed"}, record[0])
+	broken = append(broken, record[2:]...)
+
+	tests := []struct {
+		name       string
+		lines      []string
+		opts       []FetcherOption
+		wantMsgs   []string
+		wantTokens bool
+	}{
+		{
+			name:  "verified",
+			lines: record,
+			wantMsgs: []string{
+				"received TXT record",
+				"JWT verified",
+			},
+		}, {
+			name:  "broken record",
+			lines: broken,
+			wantMsgs: []string{
+				"received TXT record",
+				"problem reassembling TXT record",
+				"problem reassembling TXT record",
+				"TXT record can't be reassembled",
+			},
+		}, {
+			name:  "token logging",
+			lines: record,
+			opts:  []FetcherOption{WithTokenLogging()},
+			wantMsgs: []string{
+				"received TXT record",
+				"JWT verified",
+			},
+			wantTokens: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			opts := []FetcherOption{
+				WithFQDN(a.fqdn),
+				WithResolver(resolverFunc(func(context.Context, string) ([]string, error) {
+					return tt.lines, nil
+				})),
+				WithParseOptions(a.provider),
+				WithLogger(newTestLogger(&buf)),
+			}
+			fetcher, err := New(append(opts, tt.opts...)...)
+			require.NoError(t, err)
+
+			_, _, _ = fetcher.Fetch(context.Background())
+
+			entries := logEntries(t, &buf)
+			msgs := make([]string, 0, len(entries))
+			for _, entry := range entries {
+				msgs = append(msgs, entry["msg"].(string))
+				assert.Equal(t, "DEBUG", entry["level"])
+				assert.Equal(t, a.fqdn, entry["fqdn"])
+			}
+			assert.Equal(t, tt.wantMsgs, msgs)
+
+			assert.Equal(t, tt.wantTokens, strings.Contains(buf.String(), string(a.jwt)))
+			assert.Equal(t, tt.wantTokens, strings.Contains(buf.String(), record[0][3:]))
+		})
+	}
+}
+
+func TestFetcherLoggingProblems(t *testing.T) {
+	var buf bytes.Buffer
+
+	fetcher, err := New(
+		WithFQDN("fqdn.example.org"),
+		WithResolver(resolverFunc(func(context.Context, string) ([]string, error) {
+			return []string{"00:header", "xx:payload", "02:signature"}, nil
+		})),
+		WithLogger(newTestLogger(&buf)),
+	)
+	require.NoError(t, err)
+
+	_, _, err = fetcher.Fetch(context.Background())
+	require.ErrorIs(t, err, ErrInvalidJWT)
+
+	entries := logEntries(t, &buf)
+	require.Len(t, entries, 4)
+
+	assert.Equal(t, "malformed line", entries[1]["problem"])
+	assert.Equal(t, "invalid index", entries[1]["reason"])
+	assert.Equal(t, float64(1), entries[1]["position"])
+	assert.Equal(t, "REDACTED(10 bytes)", entries[1]["line"])
+	assert.NotContains(t, entries[1], "index")
+
+	assert.Equal(t, "missing chunk", entries[2]["problem"])
+	assert.Equal(t, float64(1), entries[2]["index"])
+	assert.NotContains(t, entries[2], "position")
+
+	assert.Equal(t, "TXT record can't be reassembled", entries[3]["msg"])
+	assert.Contains(t, entries[3]["error"], "missing chunk")
+}
+
+func TestCreateRecordLogging(t *testing.T) {
+	var buf bytes.Buffer
+	token := strings.Repeat("a", 300)
+
+	lines, err := CreateRecord(token, WithLogger(newTestLogger(&buf)))
+	require.NoError(t, err)
+
+	_, err = CreateRecord(token, WithLogger(newTestLogger(&buf)), WithMaxSize(100))
+	require.ErrorIs(t, err, ErrInvalidInput)
+
+	entries := logEntries(t, &buf)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "created TXT record", entries[0]["msg"])
+	assert.Equal(t, float64(len(lines)), entries[0]["lines"])
+	assert.Equal(t, float64(306), entries[0]["size"])
+	assert.Equal(t, "REDACTED(300 bytes)", entries[0]["token"])
+
+	assert.Equal(t, "TXT record is too large", entries[1]["msg"])
+	assert.Equal(t, float64(100), entries[1]["max_size"])
+
+	buf.Reset()
+	_, err = CreateRecord(token, WithLogger(newTestLogger(&buf)), WithTokenLogging())
+	require.NoError(t, err)
+	assert.Equal(t, token, logEntries(t, &buf)[0]["token"])
+}
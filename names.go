@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// DeviceIDPlaceholder is replaced by the device ID in a name template.
+	DeviceIDPlaceholder = "{device_id}"
+
+	// BaseDomainPlaceholder is replaced by the base domain in a name template.
+	BaseDomainPlaceholder = "{base}"
+
+	// DefaultNameTemplate is the name template used if none is set.
+	DefaultNameTemplate = DeviceIDPlaceholder + "." + BaseDomainPlaceholder
+
+	maxNameLength  = 253
+	maxLabelLength = 63
+)
+
+// buildName fills in the template with the device ID and base domain and
+// normalizes the result.  The device ID must be a single label, so it can't
+// add labels or placeholders to the name.
+func buildName(tmpl, deviceID, base string) (string, error) {
+	if err := validateLabel(strings.ToLower(deviceID)); err != nil {
+		return "", fmt.Errorf("%w device id %q: %w", ErrInvalidInput, deviceID, err)
+	}
+
+	name := strings.ReplaceAll(tmpl, DeviceIDPlaceholder, deviceID)
+	name = strings.ReplaceAll(name, BaseDomainPlaceholder, base)
+
+	return normalizeName(name)
+}
+
+// normalizeName lower-cases the name, removes any trailing dot and validates
+// the name against the DNS length limits.  Labels may contain letters, digits
+// and hyphens, but not start or end with a hyphen.  A label may start with an
+// underscore, like _jwt, to allow for service style labels.
+func normalizeName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+
+	if name == "" {
+		return "", fmt.Errorf("%w name is empty", ErrInvalidInput)
+	}
+	if len(name) > maxNameLength {
+		return "", fmt.Errorf("%w name %q is longer than %d", ErrInvalidInput, name, maxNameLength)
+	}
+
+	for _, label := range strings.Split(name, ".") {
+		if err := validateLabel(label); err != nil {
+			return "", fmt.Errorf("%w name %q: %w", ErrInvalidInput, name, err)
+		}
+	}
+
+	return name, nil
+}
+
+func validateLabel(label string) error {
+	if label == "" {
+		return errors.New("empty label")
+	}
+	if len(label) > maxLabelLength {
+		return fmt.Errorf("label %q is longer than %d", label, maxLabelLength)
+	}
+
+	body := strings.TrimPrefix(label, "_")
+	if body == "" || body[0] == '-' || body[len(body)-1] == '-' {
+		return fmt.Errorf("label %q is invalid", label)
+	}
+
+	for _, c := range body {
+		switch {
+		case c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9':
+		case c == '-':
+		default:
+			return fmt.Errorf("label %q has invalid character %q", label, c)
+		}
+	}
+
+	return nil
+}
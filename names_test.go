@@ -0,0 +1,163 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildName(t *testing.T) {
+	tests := []struct {
+		name     string
+		tmpl     string
+		deviceID string
+		base     string
+		expected string
+		err      bool
+	}{
+		{
+			name:     "default template",
+			tmpl:     DefaultNameTemplate,
+			deviceID: "112233445566",
+			base:     "example.org",
+			expected: "112233445566.example.org",
+		}, {
+			name:     "underscore label and lower casing",
+			tmpl:     "{device_id}._jwt.{base}",
+			deviceID: "AABBCCDDEEFF",
+			base:     "Example.ORG.",
+			expected: "aabbccddeeff._jwt.example.org",
+		}, {
+			name:     "hyphens are allowed inside a label",
+			tmpl:     DefaultNameTemplate,
+			deviceID: "device-1",
+			base:     "example.org",
+			expected: "device-1.example.org",
+		}, {
+			name:     "invalid character",
+			tmpl:     DefaultNameTemplate,
+			deviceID: "mac:112233445566",
+			base:     "example.org",
+			err:      true,
+		}, {
+			name:     "leading hyphen",
+			tmpl:     DefaultNameTemplate,
+			deviceID: "-device",
+			base:     "example.org",
+			err:      true,
+		}, {
+			name:     "underscore only label",
+			tmpl:     "{device_id}._.{base}",
+			deviceID: "device",
+			base:     "example.org",
+			err:      true,
+		}, {
+			name:     "empty label",
+			tmpl:     DefaultNameTemplate,
+			deviceID: "device.",
+			base:     "example.org",
+			err:      true,
+		}, {
+			name:     "more than one label",
+			tmpl:     DefaultNameTemplate,
+			deviceID: "a.b",
+			base:     "example.org",
+			err:      true,
+		}, {
+			name:     "placeholder",
+			tmpl:     DefaultNameTemplate,
+			deviceID: BaseDomainPlaceholder,
+			base:     "example.org",
+			err:      true,
+		}, {
+			name:     "label too long",
+			tmpl:     DefaultNameTemplate,
+			deviceID: strings.Repeat("a", 64),
+			base:     "example.org",
+			err:      true,
+		}, {
+			name:     "name too long",
+			tmpl:     DefaultNameTemplate,
+			deviceID: strings.Repeat("a", 60),
+			base:     strings.Repeat(strings.Repeat("a", 60)+".", 3) + "example.org",
+			err:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildName(tt.tmpl, tt.deviceID, tt.base)
+			if tt.err {
+				assert.ErrorIs(t, err, ErrInvalidInput)
+				assert.Empty(t, got)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestFetchFor(t *testing.T) {
+	a, err := MakeTrustedSet("aabbccddeeff._jwt.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+
+	var queried []string
+	resolver := resolverFunc(func(ctx context.Context, name string) ([]string, error) {
+		queried = append(queried, name)
+		if name == "aabbccddeeff._jwt.dr.example.org" {
+			return a.resolver.LookupTXT(ctx, a.fqdn)
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	})
+
+	fetcher, err := New(
+		WithBaseDomains("Example.org", "dr.example.org"),
+		WithNameTemplate("{device_id}._jwt.{base}"),
+		WithResolver(resolver),
+		WithParseOptions(a.provider),
+	)
+	require.NoError(t, err)
+
+	res, err := fetcher.FetchResultFor(context.Background(), "AABBCCDDEEFF")
+	require.NoError(t, err)
+	assert.Equal(t, "aabbccddeeff._jwt.dr.example.org", res.FQDN)
+	assert.Equal(t, a.payload, res.Payload)
+	assert.Equal(t, []string{
+		"aabbccddeeff._jwt.example.org",
+		"aabbccddeeff._jwt.dr.example.org",
+	}, queried)
+
+	token, buf, err := fetcher.FetchFor(context.Background(), "bad:id")
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	assert.Nil(t, token)
+	assert.Nil(t, buf)
+
+	// No FQDN was set, so Fetch can't be used.
+	token, buf, err = fetcher.Fetch(context.Background())
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	assert.Nil(t, token)
+	assert.Nil(t, buf)
+}
+
+func TestFetchForOptions(t *testing.T) {
+	_, err := New(WithBaseDomain("example.org"), WithNameTemplate("jwt.{base}"))
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	_, err = New(WithBaseDomain("exa mple.org"))
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	fetcher, err := New(WithFQDN("fqdn.example.org"))
+	require.NoError(t, err)
+
+	_, _, err = fetcher.FetchFor(context.Background(), "device")
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
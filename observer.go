@@ -0,0 +1,224 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+// Observer receives events about the lookups and verifications a Fetcher
+// performs.  The methods are called synchronously from the goroutine doing
+// the work, so they must be safe for concurrent use and return quickly.
+type Observer interface {
+	// OnLookupStart is called before each TXT lookup, including retries.
+	OnLookupStart(LookupStartEvent)
+
+	// OnLookupDone is called after each TXT lookup, including retries.
+	OnLookupDone(LookupDoneEvent)
+
+	// OnReassembly is called when problems are found with the lines of a
+	// record while reassembling the JWT.
+	OnReassembly(ReassemblyEvent)
+
+	// OnVerify is called after the reassembled JWT is verified.
+	OnVerify(VerifyEvent)
+}
+
+// LookupStartEvent describes a TXT lookup that is starting.
+type LookupStartEvent struct {
+	// FQDN is the name looked up.
+	FQDN string
+
+	// Attempt is the attempt number, starting at 1.
+	Attempt int
+}
+
+// LookupDoneEvent describes a TXT lookup that finished.
+type LookupDoneEvent struct {
+	// FQDN is the name looked up.
+	FQDN string
+
+	// Attempt is the attempt number, starting at 1.
+	Attempt int
+
+	// Latency is how long the lookup took.
+	Latency time.Duration
+
+	// Lines is the number of lines in the answer.
+	Lines int
+
+	// Bytes is the total length of the lines in the answer.
+	Bytes int
+
+	// Err is the error, if the lookup failed.
+	Err error
+}
+
+// ProblemKind is the kind of problem found with a line of a record.
+type ProblemKind int
+
+const (
+	// MalformedLine is a line without a valid "nn:" index prefix.
+	MalformedLine ProblemKind = iota + 1
+
+	// DuplicateChunk is a line with the same index as an earlier line.  The
+	// later line is used.
+	DuplicateChunk
+
+	// MissingChunk is an index with no line, which prevents reassembly.
+	MissingChunk
+
+	// InconsistentIndex is a line with an index that isn't written the way
+	// CreateRecord writes it, such as "1" or "001" instead of "01".  It is
+	// only reported in strict mode.
+	InconsistentIndex
+)
+
+// String returns the name of the problem kind.
+func (k ProblemKind) String() string {
+	switch k {
+	case MalformedLine:
+		return "malformed line"
+	case DuplicateChunk:
+		return "duplicate chunk"
+	case MissingChunk:
+		return "missing chunk"
+	case InconsistentIndex:
+		return "inconsistent index"
+	}
+
+	return "unknown"
+}
+
+// err returns the sentinel error for the problem kind.
+func (k ProblemKind) err() error {
+	switch k {
+	case MalformedLine:
+		return ErrMalformedLine
+	case DuplicateChunk:
+		return ErrDuplicateChunk
+	case MissingChunk:
+		return ErrMissingChunk
+	case InconsistentIndex:
+		return ErrInconsistentIndex
+	}
+
+	return nil
+}
+
+// ReassemblyProblem is a problem found with the lines of a record.
+type ReassemblyProblem struct {
+	// Kind is the kind of problem.
+	Kind ProblemKind
+
+	// Index is the index of the chunk, or -1 if the line is malformed.
+	Index int
+
+	// Line is the position of the line in the answer, or -1 if the chunk is
+	// missing.
+	Line int
+
+	// Reason describes the problem.
+	Reason string
+}
+
+// String describes the problem, naming the line or the missing index.
+func (p ReassemblyProblem) String() string {
+	if p.Line < 0 {
+		return fmt.Sprintf("index %d: %s: %s", p.Index, p.Kind, p.Reason)
+	}
+
+	return fmt.Sprintf("line %d: %s: %s", p.Line, p.Kind, p.Reason)
+}
+
+// ReassemblyEvent describes the problems found while reassembling a record.
+type ReassemblyEvent struct {
+	// FQDN is the name of the record, or empty if the record was obtained out
+	// of band.
+	FQDN string
+
+	// Lines is the number of lines in the record.
+	Lines int
+
+	// Problems are the problems found, in the order they were found.
+	Problems []ReassemblyProblem
+}
+
+// VerifyEvent describes the outcome of verifying a JWT.  The claims are taken
+// from the JWT even if it fails verification, if it can be parsed.
+type VerifyEvent struct {
+	// FQDN is the name of the record, or empty if the record was obtained out
+	// of band.
+	FQDN string
+
+	// Issuer is the iss claim.
+	Issuer string
+
+	// Subject is the sub claim.
+	Subject string
+
+	// Expiration is the exp claim.
+	Expiration time.Time
+
+	// KeyID is the kid header.
+	KeyID string
+
+	// Err is the error, if verification failed.
+	Err error
+}
+
+// observers sends the events to each of the observers in order.
+type observers []Observer
+
+func (o observers) OnLookupStart(e LookupStartEvent) {
+	for _, obs := range o {
+		obs.OnLookupStart(e)
+	}
+}
+
+func (o observers) OnLookupDone(e LookupDoneEvent) {
+	for _, obs := range o {
+		obs.OnLookupDone(e)
+	}
+}
+
+func (o observers) OnReassembly(e ReassemblyEvent) {
+	for _, obs := range o {
+		obs.OnReassembly(e)
+	}
+}
+
+func (o observers) OnVerify(e VerifyEvent) {
+	for _, obs := range o {
+		obs.OnVerify(e)
+	}
+}
+
+// newVerifyEvent creates the event for the JWT, reading the claims without
+// verifying them if the verification failed.
+func newVerifyEvent(fqdn, txt string, token jwt.Token, err error) VerifyEvent {
+	e := VerifyEvent{
+		FQDN: fqdn,
+		Err:  err,
+	}
+
+	if token == nil {
+		token, _ = jwt.ParseInsecure([]byte(txt))
+	}
+	if token != nil {
+		e.Issuer = token.Issuer()
+		e.Subject = token.Subject()
+		e.Expiration = token.Expiration()
+	}
+
+	if msg, err := jws.Parse([]byte(txt)); err == nil && len(msg.Signatures()) > 0 {
+		e.KeyID = msg.Signatures()[0].ProtectedHeaders().KeyID()
+	}
+
+	return e
+}
@@ -0,0 +1,200 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"expvar"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is an Observer that records the events.
+type recorder struct {
+	mu     sync.Mutex
+	events []any
+}
+
+func (r *recorder) record(e any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func (r *recorder) OnLookupStart(e LookupStartEvent) { r.record(e) }
+func (r *recorder) OnLookupDone(e LookupDoneEvent)   { r.record(e) }
+func (r *recorder) OnReassembly(e ReassemblyEvent)   { r.record(e) }
+func (r *recorder) OnVerify(e VerifyEvent)           { r.record(e) }
+
+func TestObserver(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+	a, err := MakeTrustedSet("fqdn.example.org", map[string]any{
+		"iss": "issuer.example.org",
+		"sub": "device",
+		"exp": exp,
+	})
+	require.NoError(t, err)
+
+	record, err := a.resolver.LookupTXT(context.Background(), a.fqdn)
+	require.NoError(t, err)
+
+	bytes := 0
+	for _, line := range record {
+		bytes += len(line)
+	}
+
+	// The first lookup times out, the second answers with a duplicated line.
+	timeout := &net.DNSError{Err: "i/o timeout", IsTimeout: true}
+	var calls atomic.Int64
+	resolver := resolverFunc(func(context.Context, string) ([]string, error) {
+		if calls.Add(1) == 1 {
+			return nil, timeout
+		}
+		return append([]string{record[0]}, record...), nil
+	})
+
+	var rec recorder
+	var counters Counters
+	fetcher, err := New(
+		WithFQDN(a.fqdn),
+		WithResolver(resolver),
+		WithParseOptions(a.provider),
+		WithRetry(RetryPolicy{Backoff: time.Millisecond}),
+		WithObserver(&rec),
+		WithObserver(&counters),
+		WithObserver(nil),
+	)
+	require.NoError(t, err)
+
+	_, buf, err := fetcher.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, a.payload, buf)
+
+	require.Len(t, rec.events, 6)
+	assert.Equal(t, LookupStartEvent{FQDN: a.fqdn, Attempt: 1}, rec.events[0])
+
+	done := rec.events[1].(LookupDoneEvent)
+	assert.Equal(t, 1, done.Attempt)
+	assert.Equal(t, timeout, done.Err)
+	assert.Zero(t, done.Lines)
+
+	assert.Equal(t, LookupStartEvent{FQDN: a.fqdn, Attempt: 2}, rec.events[2])
+
+	done = rec.events[3].(LookupDoneEvent)
+	assert.Equal(t, 2, done.Attempt)
+	assert.NoError(t, done.Err)
+	assert.Equal(t, len(record)+1, done.Lines)
+	assert.Equal(t, bytes+len(record[0]), done.Bytes)
+	assert.Positive(t, done.Latency)
+
+	assert.Equal(t, ReassemblyEvent{
+		FQDN:  a.fqdn,
+		Lines: len(record) + 1,
+		Problems: []ReassemblyProblem{{
+			Kind:   DuplicateChunk,
+			Index:  0,
+			Line:   1,
+			Reason: "replaces an earlier line with the same index",
+		}},
+	}, rec.events[4])
+
+	assert.Equal(t, VerifyEvent{
+		FQDN:       a.fqdn,
+		Issuer:     "issuer.example.org",
+		Subject:    "device",
+		Expiration: exp.UTC(),
+	}, rec.events[5])
+
+	stats := counters.Stats()
+	assert.Equal(t, int64(2), stats.Lookups)
+	assert.Equal(t, int64(1), stats.LookupErrors)
+	assert.Equal(t, int64(len(record)+1), stats.Lines)
+	assert.Equal(t, int64(1), stats.ReassemblyProblems)
+	assert.Equal(t, int64(1), stats.Verifications)
+	assert.Zero(t, stats.VerifyErrors)
+	assert.GreaterOrEqual(t, stats.LookupLatency, stats.MaxLookupLatency)
+}
+
+func TestObserverVerifyFailure(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	key, err := jwk.FromRaw(priv)
+	require.NoError(t, err)
+	require.NoError(t, key.Set(jwk.KeyIDKey, "key-1"))
+
+	token := jwt.New()
+	require.NoError(t, token.Set(jwt.IssuerKey, "issuer.example.org"))
+
+	signed, err := jwt.Sign(token, jwt.WithKey(jwa.ES256, key))
+	require.NoError(t, err)
+
+	record, err := CreateRecord(string(signed))
+	require.NoError(t, err)
+
+	// The record verifies with another key, so verification fails.
+	other, err := MakePublicKeySet("fqdn.example.org", nil)
+	require.NoError(t, err)
+
+	var rec recorder
+	var counters Counters
+	fetcher, err := New(
+		WithFQDN("fqdn.example.org"),
+		WithResolver(resolverFunc(func(context.Context, string) ([]string, error) {
+			return record, nil
+		})),
+		WithParseOptions(other.provider),
+		WithObserver(&rec),
+		WithObserver(&counters),
+	)
+	require.NoError(t, err)
+
+	_, _, err = fetcher.Fetch(context.Background())
+	require.ErrorIs(t, err, ErrInvalidJWT)
+
+	require.Len(t, rec.events, 3)
+	verify := rec.events[2].(VerifyEvent)
+	assert.ErrorIs(t, verify.Err, ErrInvalidJWT)
+	assert.Equal(t, "issuer.example.org", verify.Issuer)
+	assert.Equal(t, "key-1", verify.KeyID)
+
+	stats := counters.Stats()
+	assert.Equal(t, int64(1), stats.Verifications)
+	assert.Equal(t, int64(1), stats.VerifyErrors)
+}
+
+func TestCountersPublish(t *testing.T) {
+	var counters Counters
+	counters.OnLookupDone(LookupDoneEvent{Latency: time.Second, Lines: 2, Bytes: 10})
+	counters.OnLookupDone(LookupDoneEvent{Latency: 3 * time.Second, Err: context.DeadlineExceeded})
+
+	require.NoError(t, counters.Publish("dnstxtjwt_test_counters"))
+
+	var stats Stats
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("dnstxtjwt_test_counters").String()), &stats))
+	assert.Equal(t, Stats{
+		Lookups:          2,
+		LookupErrors:     1,
+		LookupLatency:    4 * time.Second,
+		MaxLookupLatency: 3 * time.Second,
+		Lines:            2,
+		Bytes:            10,
+	}, stats)
+
+	assert.ErrorIs(t, counters.Publish("dnstxtjwt_test_counters"), ErrInvalidInput)
+	assert.ErrorIs(t, counters.Publish(""), ErrInvalidInput)
+}
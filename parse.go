@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"strings"
+)
+
+type parseRecord struct {
+	strict bool
+}
+
+// ParseRecordOption is the interface that all ParseRecord options must
+// implement.
+type ParseRecordOption interface {
+	applyParse(*parseRecord)
+}
+
+// ParseRecord reassembles the JWT from the lines of a TXT record, using the
+// same rules as the Fetcher.  It is the inverse of CreateRecord: the lines
+// CreateRecord creates for a JWT, in any order, parse back into the same JWT.
+// Use WithStrictReassembly to reject records with any problem, as the Fetcher
+// does with the same option.
+//
+// The lines may be quoted, as they are in a zone file or the output of dig,
+// and the quotes are removed.  The JWT is not verified.  If the record can't
+// be reassembled, such as when a chunk is missing, a *RecordError describing
+// the problems is returned.
+func ParseRecord(lines []string, opts ...ParseRecordOption) (string, error) {
+	var p parseRecord
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt.applyParse(&p)
+		}
+	}
+
+	unquoted := make([]string, 0, len(lines))
+	for _, line := range lines {
+		unquoted = append(unquoted, unquote(line))
+	}
+
+	txt, problems := reassembleLines(unquoted, p.strict)
+	if txt == "" {
+		return "", newRecordError(unquoted, problems)
+	}
+
+	return txt, nil
+}
+
+// unquote removes the double quotes around a line, if it has them.
+func unquote(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if len(trimmed) >= 2 && trimmed[0] == '"' && trimmed[len(trimmed)-1] == '"' {
+		return trimmed[1 : len(trimmed)-1]
+	}
+
+	return line
+}
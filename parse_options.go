@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+// ReassemblyOption is an option that applies to both a Fetcher and
+// ParseRecord.
+type ReassemblyOption interface {
+	FetcherOption
+	ParseRecordOption
+}
+
+type reassemblyOptionFunc func(*bool)
+
+func (f reassemblyOptionFunc) apply(r *Fetcher) error {
+	f(&r.strict)
+	return nil
+}
+
+func (f reassemblyOptionFunc) applyParse(p *parseRecord) {
+	f(&p.strict)
+}
+
+// WithStrictReassembly rejects records with any problem instead of
+// reassembling them as well as possible.  Without it, a line that isn't part
+// of the record is skipped, a later line replaces an earlier one with the same
+// index, and the index may be written any way, so a record that mixes the
+// lines of an old and a new JWT during a zone update may reassemble into
+// garbage.  With it, a record is rejected if it has a line that isn't part of
+// the record, two lines with the same index, indexes that don't start at 0, a
+// gap in the indexes, or an index that isn't written the way CreateRecord
+// writes it.
+func WithStrictReassembly() ReassemblyOption {
+	return reassemblyOptionFunc(
+		func(strict *bool) {
+			*strict = true
+		},
+	)
+}
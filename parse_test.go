@@ -0,0 +1,240 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRecord(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		opts     []ParseRecordOption
+		want     string
+		wantErrs []error
+		wantMsg  string
+	}{
+		{
+			name:  "valid",
+			lines: []string{"00:header", "01:.payload.", "02:signature"},
+			want:  "header.payload.signature",
+		}, {
+			name:  "valid, strict",
+			lines: []string{"00:header", "01:.payload.", "02:signature"},
+			opts:  []ParseRecordOption{WithStrictReassembly()},
+			want:  "header.payload.signature",
+		}, {
+			name:  "out of order",
+			lines: []string{"02:signature", "00:header", "01:.payload."},
+			opts:  []ParseRecordOption{WithStrictReassembly()},
+			want:  "header.payload.signature",
+		}, {
+			name:  "quoted",
+			lines: []string{`"00:header"`, ` "01:.payload." `, `"02:signature"`},
+			opts:  []ParseRecordOption{WithStrictReassembly()},
+			want:  "header.payload.signature",
+		}, {
+			name:     "unbalanced quotes, strict",
+			lines:    []string{`"00:header`, `01:.payload.`, `02:signature"`},
+			opts:     []ParseRecordOption{WithStrictReassembly()},
+			wantErrs: []error{ErrMalformedLine, ErrMissingChunk},
+			wantMsg:  "line 0: malformed line: invalid index; index 0: missing chunk: no line has the index",
+		}, {
+			name:  "duplicate chunk",
+			lines: []string{"00:header", "01:.old.", "01:.payload.", "02:signature"},
+			want:  "header.payload.signature",
+		}, {
+			name:     "starting from 1, strict",
+			lines:    []string{"01:header", "02:.payload.", "03:signature"},
+			opts:     []ParseRecordOption{WithStrictReassembly()},
+			wantErrs: []error{ErrMissingChunk},
+			wantMsg:  "index 0: missing chunk: no line has the index",
+		}, {
+			name:     "duplicate chunk, strict",
+			lines:    []string{"00:header", "01:.old.", "01:.payload.", "02:signature"},
+			opts:     []ParseRecordOption{nil, WithStrictReassembly()},
+			wantErrs: []error{ErrDuplicateChunk},
+			wantMsg:  "line 2: duplicate chunk: has the same index as line 1",
+		}, {
+			name:     "stray line, strict",
+			lines:    []string{"v=spf1 -all", "00:header", "01:.payload.", "02:signature"},
+			opts:     []ParseRecordOption{WithStrictReassembly()},
+			wantErrs: []error{ErrMalformedLine},
+			wantMsg:  "line 0: malformed line: missing ':' separator",
+		}, {
+			name:     "inconsistent index, strict",
+			lines:    []string{"00:header", "1:.payload.", "02:signature"},
+			opts:     []ParseRecordOption{WithStrictReassembly()},
+			wantErrs: []error{ErrInconsistentIndex},
+			wantMsg:  `line 1: inconsistent index: the index should be written as "01"`,
+		}, {
+			name:     "missing chunk",
+			lines:    []string{"00:header", "02:signature"},
+			wantErrs: []error{ErrMissingChunk},
+			wantMsg:  "index 1: missing chunk: no line has the index",
+		}, {
+			name:     "empty record",
+			lines:    []string{"v=spf1 -all"},
+			wantErrs: []error{ErrEmptyRecord, ErrMalformedLine},
+			wantMsg:  "empty record: the record has no chunks; line 0: malformed line: missing ':' separator",
+		}, {
+			name:     "empty chunks",
+			lines:    []string{"00:"},
+			wantErrs: []error{ErrEmptyRecord},
+			wantMsg:  "empty record: the record has no chunks",
+		}, {
+			name:     "empty chunks, strict",
+			lines:    []string{"00:", "01: "},
+			opts:     []ParseRecordOption{WithStrictReassembly()},
+			wantErrs: []error{ErrEmptyRecord},
+			wantMsg:  "empty record: the record has no chunks",
+		}, {
+			name:     "no lines",
+			wantErrs: []error{ErrEmptyRecord},
+			wantMsg:  "empty record: the record has no chunks",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRecord(tt.lines, tt.opts...)
+			assert.Equal(t, tt.want, got)
+
+			if tt.wantErrs == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var re *RecordError
+			require.ErrorAs(t, err, &re)
+			if len(re.Problems) == 0 {
+				assert.ErrorIs(t, err, ErrEmptyRecord)
+			}
+			for _, want := range tt.wantErrs {
+				assert.ErrorIs(t, err, want)
+			}
+			assert.Equal(t, tt.wantMsg, err.Error())
+		})
+	}
+}
+
+func TestParseRecordInverse(t *testing.T) {
+	// The characters of a JWT in the compact serialization.
+	alphabet := "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_."
+
+	tests := []struct {
+		name          string
+		size          int
+		maxLineLength int
+	}{
+		{name: "one line", size: 100},
+		{name: "full lines", size: 251 * 3},
+		{name: "short lines", size: 1000, maxLineLength: 10},
+		{name: "over 100 lines", size: 5000, maxLineLength: 20},
+		{name: "over 1000 lines", size: 15000, maxLineLength: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			for i := range tt.size {
+				b.WriteByte(alphabet[i%len(alphabet)])
+			}
+			jwt := b.String()
+
+			lines, err := CreateRecord(jwt, WithMaxLineLength(tt.maxLineLength), WithMaxSize(65270))
+			require.NoError(t, err)
+
+			got, err := ParseRecord(lines, WithStrictReassembly())
+			require.NoError(t, err)
+			assert.Equal(t, jwt, got)
+
+			// The order of the lines in the answer doesn't matter.
+			slices.Reverse(lines)
+			got, err = ParseRecord(lines, WithStrictReassembly())
+			require.NoError(t, err)
+			assert.Equal(t, jwt, got)
+		})
+	}
+}
+
+func TestFetchStrictReassembly(t *testing.T) {
+	a, err := MakePublicKeySet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+	b, err := MakePublicKeySet("fqdn.example.org", map[string]any{"example": "B"})
+	require.NoError(t, err)
+
+	recordA, err := CreateRecord(string(a.jwt), WithMaxLineLength(100))
+	require.NoError(t, err)
+	recordB, err := CreateRecord(string(b.jwt), WithMaxLineLength(100))
+	require.NoError(t, err)
+
+	// During a zone update, a resolver may see the lines of both records.  The
+	// later line wins, so the record reassembles into garbage that only fails
+	// to verify, unless strict reassembly is used.
+	mixed := append(append([]string{}, recordA...), recordB[len(recordB)-1])
+
+	tests := []struct {
+		name      string
+		lines     []string
+		opts      []FetcherOption
+		wantErr   error
+		wantStage Stage
+	}{
+		{
+			name:  "valid",
+			lines: recordB,
+		}, {
+			name:  "valid, strict",
+			lines: recordB,
+			opts:  []FetcherOption{WithStrictReassembly()},
+		}, {
+			name:      "mixed",
+			lines:     mixed,
+			wantErr:   ErrBadSignature,
+			wantStage: StageVerify,
+		}, {
+			name:      "mixed, strict",
+			lines:     mixed,
+			opts:      []FetcherOption{WithStrictReassembly()},
+			wantErr:   ErrDuplicateChunk,
+			wantStage: StageReassemble,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []FetcherOption{
+				WithFQDN(b.fqdn),
+				WithResolver(resolverFunc(func(context.Context, string) ([]string, error) {
+					return tt.lines, nil
+				})),
+				WithParseOptions(b.provider),
+			}
+			fetcher, err := New(append(opts, tt.opts...)...)
+			require.NoError(t, err)
+
+			_, buf, err := fetcher.Fetch(context.Background())
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				assert.Equal(t, b.payload, buf)
+				return
+			}
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.ErrorIs(t, err, ErrInvalidJWT)
+
+			var fe *FetchError
+			require.ErrorAs(t, err, &fe)
+			assert.Equal(t, tt.wantStage, fe.Stage)
+		})
+	}
+}
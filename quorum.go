@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// QuorumError is returned when not enough of the resolvers agree on the
+// record.  It matches ErrQuorum with errors.Is.
+type QuorumError struct {
+	// Required is the number of resolvers that needed to agree.
+	Required int
+
+	// Groups are the indexes of the resolvers that answered, grouped by
+	// identical records, largest group first.
+	Groups [][]int
+
+	// Failed are the indexes of the resolvers that didn't answer with a
+	// record that reassembled.
+	Failed []int
+
+	// Errs are the errors from the resolvers that failed.
+	Errs []error
+}
+
+func (e *QuorumError) Error() string {
+	var buf strings.Builder
+
+	fmt.Fprintf(&buf, "%s: %d agreeing resolvers required", ErrQuorum, e.Required)
+	if len(e.Groups) > 0 {
+		buf.WriteString(", resolvers disagreed:")
+		for _, group := range e.Groups {
+			fmt.Fprintf(&buf, " %v", group)
+		}
+	}
+	if len(e.Failed) > 0 {
+		fmt.Fprintf(&buf, ", resolvers failed: %v", e.Failed)
+	}
+
+	return buf.String()
+}
+
+func (e *QuorumError) Is(target error) bool {
+	return target == ErrQuorum
+}
+
+func (e *QuorumError) Unwrap() []error {
+	return e.Errs
+}
+
+// QuorumResolvers returns a Resolver that sends the same lookup to each of the
+// resolvers and only answers once at least m of them return records that
+// reassemble into byte-identical JWTs.  The remaining lookups are canceled
+// once the quorum is reached.  If the quorum can't be reached, or the context
+// is done first, a *QuorumError is returned.
+func QuorumResolvers(m int, resolvers ...Resolver) (Resolver, error) {
+	if m < 1 || m > len(resolvers) {
+		return nil, fmt.Errorf("%w quorum must be between 1 and the number of resolvers", ErrInvalidInput)
+	}
+
+	return &quorumResolver{
+		required:  m,
+		resolvers: resolvers,
+	}, nil
+}
+
+type quorumResolver struct {
+	required  int
+	resolvers []Resolver
+}
+
+type quorumAnswer struct {
+	index int
+	lines []string
+	err   error
+}
+
+func (q *quorumResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The channel is buffered so the remaining lookups can always exit.
+	answers := make(chan quorumAnswer, len(q.resolvers))
+	for i, resolver := range q.resolvers {
+		go func() {
+			lines, err := resolver.LookupTXT(ctx, name)
+			answers <- quorumAnswer{index: i, lines: lines, err: err}
+		}()
+	}
+
+	qerr := QuorumError{
+		Required: q.required,
+	}
+	records := make(map[string][]int, len(q.resolvers))
+
+	// A resolver that ignores the context must not hold up the lookup once
+	// the caller gives up.
+answers:
+	for range q.resolvers {
+		var a quorumAnswer
+		select {
+		case a = <-answers:
+		case <-ctx.Done():
+			qerr.Errs = append(qerr.Errs, ctx.Err())
+			break answers
+		}
+
+		if a.err == nil && reassemble(a.lines) == "" {
+			a.err = errors.New("record did not reassemble")
+		}
+		if a.err != nil {
+			qerr.Failed = append(qerr.Failed, a.index)
+			qerr.Errs = append(qerr.Errs, fmt.Errorf("resolver %d: %w", a.index, a.err))
+			continue
+		}
+
+		record := reassemble(a.lines)
+		records[record] = append(records[record], a.index)
+		if len(records[record]) >= q.required {
+			return a.lines, nil
+		}
+	}
+
+	for _, group := range records {
+		slices.Sort(group)
+		qerr.Groups = append(qerr.Groups, group)
+	}
+	slices.SortFunc(qerr.Groups, func(a, b []int) int {
+		if len(a) != len(b) {
+			return len(b) - len(a)
+		}
+		return a[0] - b[0]
+	})
+	slices.Sort(qerr.Failed)
+
+	return nil, &qerr
+}
@@ -0,0 +1,188 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithQuorum(t *testing.T) {
+	records, provider := rotatingRecords(t,
+		map[string]any{"example": "new"},
+		map[string]any{"example": "old"},
+	)
+
+	answer := func(lines []string) Resolver {
+		return resolverFunc(func(context.Context, string) ([]string, error) {
+			return lines, nil
+		})
+	}
+	current, old := answer(records[0]), answer(records[1])
+	broken := failingResolver(errors.New("servfail"))
+
+	tests := []struct {
+		name       string
+		m          int
+		resolvers  []Resolver
+		newErr     bool
+		wantGroups [][]int
+		wantFailed []int
+	}{
+		{
+			name:      "all agree",
+			m:         3,
+			resolvers: []Resolver{current, current, current},
+		}, {
+			name:      "a poisoned resolver is outvoted",
+			m:         2,
+			resolvers: []Resolver{old, current, current},
+		}, {
+			name:      "a failed resolver is tolerated",
+			m:         2,
+			resolvers: []Resolver{current, broken, current},
+		}, {
+			name:       "resolvers disagree",
+			m:          2,
+			resolvers:  []Resolver{old, current, broken},
+			wantGroups: [][]int{{0}, {1}},
+			wantFailed: []int{2},
+		}, {
+			name:       "a majority that is not enough",
+			m:          3,
+			resolvers:  []Resolver{current, old, current},
+			wantGroups: [][]int{{0, 2}, {1}},
+		}, {
+			name:       "records that don't reassemble don't count",
+			m:          2,
+			resolvers:  []Resolver{answer([]string{"02:a"}), answer([]string{"02:a"}), current},
+			wantGroups: [][]int{{2}},
+			wantFailed: []int{0, 1},
+		}, {
+			name:      "quorum too large",
+			m:         3,
+			resolvers: []Resolver{current, current},
+			newErr:    true,
+		}, {
+			name:      "quorum too small",
+			resolvers: []Resolver{current, current},
+			newErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher, err := New(
+				WithFQDN("fqdn.example.org"),
+				WithQuorum(tt.m, tt.resolvers...),
+				WithParseOptions(provider),
+			)
+			if tt.newErr {
+				assert.Nil(t, fetcher)
+				assert.ErrorIs(t, err, ErrInvalidInput)
+				return
+			}
+			require.NoError(t, err)
+
+			token, _, err := fetcher.Fetch(context.Background())
+			if tt.wantGroups != nil {
+				assert.Nil(t, token)
+				require.ErrorIs(t, err, ErrQuorum)
+
+				var qerr *QuorumError
+				require.ErrorAs(t, err, &qerr)
+				assert.Equal(t, tt.m, qerr.Required)
+				assert.Equal(t, tt.wantGroups, qerr.Groups)
+				assert.Equal(t, tt.wantFailed, qerr.Failed)
+				return
+			}
+
+			require.NoError(t, err)
+			got, _ := token.Get("example")
+			assert.Equal(t, "new", got)
+		})
+	}
+}
+
+func TestQuorumLookupKind(t *testing.T) {
+	records, provider := rotatingRecords(t,
+		map[string]any{"example": "new"},
+		map[string]any{"example": "old"},
+	)
+
+	answer := func(lines []string) Resolver {
+		return resolverFunc(func(context.Context, string) ([]string, error) {
+			return lines, nil
+		})
+	}
+	notFound := failingResolver(&net.DNSError{Err: "no such host", IsNotFound: true})
+	timeout := failingResolver(&net.DNSError{Err: "i/o timeout", IsTimeout: true})
+
+	fetcher, err := New(
+		WithFQDN("fqdn.example.org"),
+		WithQuorum(2, answer(records[0]), answer(records[1]), notFound, timeout),
+		WithParseOptions(provider),
+	)
+	require.NoError(t, err)
+
+	// The resolvers disagree, which isn't a missing record or a timeout.
+	_, _, err = fetcher.Fetch(context.Background())
+	require.ErrorIs(t, err, ErrQuorum)
+	assert.NotErrorIs(t, err, ErrNotFound)
+	assert.NotErrorIs(t, err, ErrTimeout)
+
+	var fe *FetchError
+	require.ErrorAs(t, err, &fe)
+	assert.Equal(t, StageLookup, fe.Stage)
+}
+
+func TestQuorumContextDone(t *testing.T) {
+	records, _ := rotatingRecords(t, map[string]any{"example": "new"})
+
+	current := resolverFunc(func(context.Context, string) ([]string, error) {
+		return records[0], nil
+	})
+
+	// The resolver ignores the context and only returns at the end of the
+	// test.
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+	stuck := resolverFunc(func(context.Context, string) ([]string, error) {
+		<-done
+		return nil, errors.New("stuck")
+	})
+
+	resolver, err := QuorumResolvers(2, current, stuck)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	lines, err := resolver.LookupTXT(ctx, "fqdn.example.org")
+	assert.Nil(t, lines)
+	require.ErrorIs(t, err, ErrQuorum)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	var qerr *QuorumError
+	require.ErrorAs(t, err, &qerr)
+	assert.Equal(t, [][]int{{0}}, qerr.Groups)
+}
+
+func TestQuorumErrorMessage(t *testing.T) {
+	err := &QuorumError{
+		Required: 2,
+		Groups:   [][]int{{0}, {1}},
+		Failed:   []int{2},
+	}
+
+	assert.Equal(t,
+		"quorum not reached: 2 agreeing resolvers required, resolvers disagreed: [0] [1], resolvers failed: [2]",
+		err.Error())
+}
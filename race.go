@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jws"
+)
+
+// AcceptFunc decides if the lines returned by a resolver for the name are
+// acceptable.
+type AcceptFunc func(ctx context.Context, name string, lines []string) error
+
+// RaceResolvers returns a Resolver that sends the same lookup to each of the
+// resolvers, starting them in order stagger apart.  The next resolver is also
+// started early if all of the running ones have failed.  The first answer that
+// is accepted wins and the remaining lookups are canceled.
+//
+// If accept is nil, an answer is accepted if the lines reassemble into a
+// JWS, but the signature is not verified.  Use WithRacingResolvers to race
+// resolvers with the full verification of a Fetcher.
+func RaceResolvers(stagger time.Duration, accept AcceptFunc, resolvers ...Resolver) Resolver {
+	if accept == nil {
+		accept = acceptJWS
+	}
+
+	return &raceResolver{
+		stagger:   stagger,
+		accept:    accept,
+		resolvers: resolvers,
+	}
+}
+
+type raceResolver struct {
+	stagger   time.Duration
+	accept    AcceptFunc
+	resolvers []Resolver
+}
+
+type raceAnswer struct {
+	lines []string
+	err   error
+}
+
+func (rr *raceResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if len(rr.resolvers) == 0 {
+		return nil, fmt.Errorf("%w no resolvers to race", ErrInvalidInput)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The channel is buffered so the lookups that lose can always exit.
+	answers := make(chan raceAnswer, len(rr.resolvers))
+
+	var launched, pending int
+	launch := func() {
+		i := launched
+		launched++
+		pending++
+
+		go func() {
+			lines, err := rr.resolvers[i].LookupTXT(ctx, name)
+			if err == nil {
+				err = rr.accept(ctx, name, lines)
+			}
+			if err != nil {
+				err = fmt.Errorf("resolver %d: %w", i, err)
+			}
+			answers <- raceAnswer{lines: lines, err: err}
+		}()
+	}
+
+	launch()
+
+	timer := time.NewTimer(rr.stagger)
+	defer timer.Stop()
+
+	errs := make([]error, 0, len(rr.resolvers))
+	for pending > 0 {
+		select {
+		case a := <-answers:
+			pending--
+			if a.err == nil {
+				return a.lines, nil
+			}
+			errs = append(errs, a.err)
+
+			// Don't wait for the stagger if nothing is running.
+			if pending == 0 && launched < len(rr.resolvers) {
+				launch()
+				timer.Reset(rr.stagger)
+			}
+		case <-timer.C:
+			if launched < len(rr.resolvers) {
+				launch()
+				timer.Reset(rr.stagger)
+			}
+		case <-ctx.Done():
+			return nil, errors.Join(append(errs, ctx.Err())...)
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// acceptJWS accepts lines that reassemble into a JWS without verifying it.
+func acceptJWS(_ context.Context, _ string, lines []string) error {
+	if _, err := jws.Parse([]byte(reassemble(lines))); err != nil {
+		return errors.Join(err, ErrInvalidJWT)
+	}
+
+	return nil
+}
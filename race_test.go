@@ -0,0 +1,134 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRaceResolvers(t *testing.T) {
+	a, err := MakeTrustedSet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+
+	good, err := a.resolver.LookupTXT(context.Background(), a.fqdn)
+	require.NoError(t, err)
+
+	canceled := make(chan struct{})
+	hanging := resolverFunc(func(ctx context.Context, _ string) ([]string, error) {
+		<-ctx.Done()
+		close(canceled)
+		return nil, ctx.Err()
+	})
+
+	answer := func(lines []string) Resolver {
+		return resolverFunc(func(context.Context, string) ([]string, error) {
+			return lines, nil
+		})
+	}
+
+	// The first resolver hangs, so the second is started after the stagger
+	// and the first is canceled once the second wins.
+	race := RaceResolvers(10*time.Millisecond, nil, hanging, answer(good))
+	lines, err := race.LookupTXT(context.Background(), a.fqdn)
+	require.NoError(t, err)
+	assert.Equal(t, good, lines)
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		require.FailNow(t, "the losing lookup was not canceled")
+	}
+
+	// A fast answer that isn't a JWS loses, and the next resolver is started
+	// right away even though the stagger is long.
+	race = RaceResolvers(time.Hour, nil, answer([]string{"00:junk"}), answer(good))
+	lines, err = race.LookupTXT(context.Background(), a.fqdn)
+	require.NoError(t, err)
+	assert.Equal(t, good, lines)
+
+	// All of the resolvers fail.
+	race = RaceResolvers(time.Millisecond, nil,
+		failingResolver(errors.New("first")),
+		failingResolver(errors.New("second")),
+	)
+	lines, err = race.LookupTXT(context.Background(), a.fqdn)
+	assert.Nil(t, lines)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "resolver 0: first")
+	assert.Contains(t, err.Error(), "resolver 1: second")
+
+	// No resolvers.
+	_, err = RaceResolvers(time.Millisecond, nil).LookupTXT(context.Background(), a.fqdn)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestWithRacingResolvers(t *testing.T) {
+	a, err := MakeTrustedSet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+
+	b, err := MakeTrustedSet("fqdn.example.org", map[string]any{"example": "B"})
+	require.NoError(t, err)
+
+	slow := resolverFunc(func(ctx context.Context, name string) ([]string, error) {
+		time.Sleep(20 * time.Millisecond)
+		return a.resolver.LookupTXT(ctx, name)
+	})
+
+	// b answers first with a validly formed JWT that isn't trusted.
+	fetcher, err := New(
+		WithFQDN(a.fqdn),
+		WithRacingResolvers(time.Millisecond, b.resolver, slow),
+		WithParseOptions(a.provider),
+	)
+	require.NoError(t, err)
+
+	_, buf, err := fetcher.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, a.payload, buf)
+
+	_, err = New(WithFQDN(a.fqdn), WithRacingResolvers(time.Millisecond))
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestVerifyConcurrent(t *testing.T) {
+	a, err := MakeTrustedSet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+
+	answer := resolverFunc(func(ctx context.Context, _ string) ([]string, error) {
+		return a.resolver.LookupTXT(ctx, a.fqdn)
+	})
+
+	// Using WithParseOptions more than once leaves spare capacity in the
+	// parse options, which concurrent verifications must not write to.
+	fetcher, err := New(
+		WithFQDN(a.fqdn),
+		WithBaseDomain("example.org"),
+		WithRacingResolvers(0, answer, answer, answer, answer),
+		WithParseOptions(a.provider),
+		WithParseOptions(jwt.WithAcceptableSkew(time.Second)),
+		WithParseOptions(jwt.WithRequiredClaim("example")),
+	)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, buf, err := fetcher.FetchFor(context.Background(), fmt.Sprintf("device-%d", i))
+			assert.NoError(t, err)
+			assert.Equal(t, a.payload, buf)
+		}()
+	}
+	wg.Wait()
+}
@@ -4,6 +4,7 @@
 package dnstxtjwt
 
 import (
+	"fmt"
 	"strings"
 )
 
@@ -23,22 +24,63 @@ import (
 //   - it doesn't really matter if we are missing something because the JWT
 //     won't compute and will be discarded.
 func reassemble(lines []string) string {
+	txt, _ := reassembleLines(lines, false)
+	return txt
+}
+
+// reassembleLines is reassemble, also reporting the problems found with the
+// lines.  If strict is set, any problem prevents reassembly, the indexes must
+// start at 0, and the index of each line must be written the way CreateRecord
+// writes it.
+func reassembleLines(lines []string, strict bool) (string, []ReassemblyProblem) {
+	var problems []ReassemblyProblem
+
 	parts := make(map[int]string, len(lines)+1)
 
 	// The value in the TXT record should be 1 (really 1, but make this tolerant
-	// of 0 based indexing)
-	parts[0] = ""
+	// of 0 based indexing).  CreateRecord starts at 0, so strict reassembly
+	// requires it.
+	if !strict {
+		parts[0] = ""
+	}
+	seen := make(map[int]int, len(lines))
 
-	for _, line := range lines {
-		segments := strings.Split(line, ":")
-		if len(segments) != 2 {
-			continue // skip empty or otherwise malformed lines.
+	for i, line := range lines {
+		n, txt, reason := parseLine(line)
+		if reason != "" {
+			// skip empty or otherwise malformed lines.
+			problems = append(problems, ReassemblyProblem{
+				Kind:   MalformedLine,
+				Index:  -1,
+				Line:   i,
+				Reason: reason,
+			})
+			continue
 		}
-		n := getIndexInt(segments[0])
-		if n < 0 {
-			continue // skip lines that don't have a valid index
+		if strict {
+			if want := fmt.Sprintf("%02d", n); !strings.HasPrefix(line, want+":") {
+				problems = append(problems, ReassemblyProblem{
+					Kind:   InconsistentIndex,
+					Index:  n,
+					Line:   i,
+					Reason: fmt.Sprintf("the index should be written as %q", want),
+				})
+			}
+		}
+		if first, found := seen[n]; found {
+			reason := "replaces an earlier line with the same index"
+			if strict {
+				reason = fmt.Sprintf("has the same index as line %d", first)
+			}
+			problems = append(problems, ReassemblyProblem{
+				Kind:   DuplicateChunk,
+				Index:  n,
+				Line:   i,
+				Reason: reason,
+			})
+		} else {
+			seen[n] = i
 		}
-		txt := strings.TrimSpace(segments[1])
 		parts[n] = txt
 	}
 
@@ -49,12 +91,41 @@ func reassemble(lines []string) string {
 	for i := 0; i < len(parts); i++ {
 		val, found := parts[i]
 		if !found {
-			return ""
+			problems = append(problems, ReassemblyProblem{
+				Kind:   MissingChunk,
+				Index:  i,
+				Line:   -1,
+				Reason: "no line has the index",
+			})
+			return "", problems
 		}
 		buf.WriteString(val)
 	}
 
-	return buf.String()
+	if strict && len(problems) > 0 {
+		return "", problems
+	}
+
+	return buf.String(), problems
+}
+
+// parseLine splits the line into its index and chunk.  If the line is
+// malformed, the reason is returned instead.
+func parseLine(line string) (int, string, string) {
+	segments := strings.Split(line, ":")
+	if len(segments) < 2 {
+		return -1, "", "missing ':' separator"
+	}
+	if len(segments) > 2 {
+		return -1, "", "more than one ':' separator"
+	}
+
+	n := getIndexInt(segments[0])
+	if n < 0 {
+		return -1, "", "invalid index"
+	}
+
+	return n, strings.TrimSpace(segments[1]), ""
 }
 
 func getIndexInt(s string) int {
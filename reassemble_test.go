@@ -5,6 +5,8 @@ package dnstxtjwt
 
 import (
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestReassemble(t *testing.T) {
@@ -92,3 +94,116 @@ func TestReassemble(t *testing.T) {
 		})
 	}
 }
+
+func TestReassembleProblems(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		expected []ReassemblyProblem
+	}{
+		{
+			name:  "No problems",
+			lines: []string{"00:header", "01:.payload.", "02:signature"},
+		}, {
+			name:  "Malformed lines",
+			lines: []string{"00:header", "01payload", "xx:payload", "01:.payload.", "02:signature"},
+			expected: []ReassemblyProblem{
+				{Kind: MalformedLine, Index: -1, Line: 1, Reason: "missing ':' separator"},
+				{Kind: MalformedLine, Index: -1, Line: 2, Reason: "invalid index"},
+			},
+		}, {
+			name:  "Extra colon",
+			lines: []string{"00:header", "01:p:ayload", "02:signature"},
+			expected: []ReassemblyProblem{
+				{Kind: MalformedLine, Index: -1, Line: 1, Reason: "more than one ':' separator"},
+				{Kind: MissingChunk, Index: 1, Line: -1, Reason: "no line has the index"},
+			},
+		}, {
+			name:  "Duplicate chunk",
+			lines: []string{"00:header", "01:.payload.", "01:.payload.", "02:signature"},
+			expected: []ReassemblyProblem{
+				{Kind: DuplicateChunk, Index: 1, Line: 2, Reason: "replaces an earlier line with the same index"},
+			},
+		}, {
+			name:  "Missing chunk",
+			lines: []string{"00:header", "02:signature"},
+			expected: []ReassemblyProblem{
+				{Kind: MissingChunk, Index: 1, Line: -1, Reason: "no line has the index"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, problems := reassembleLines(tt.lines, false)
+			assert.Equal(t, tt.expected, problems)
+		})
+	}
+}
+
+func TestReassembleStrict(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		want     string
+		expected []ReassemblyProblem
+	}{
+		{
+			name:  "Valid JWT parts starting from 0",
+			lines: []string{"00:header", "01:.payload.", "02:signature"},
+			want:  "header.payload.signature",
+		}, {
+			name:  "Valid JWT parts starting from 1",
+			lines: []string{"01:header", "02:.payload.", "03:signature"},
+			expected: []ReassemblyProblem{
+				{Kind: MissingChunk, Index: 0, Line: -1, Reason: "no line has the index"},
+			},
+		}, {
+
+			name:  "Two digit indexes",
+			lines: []string{"00:a", "01:b", "02:c", "03:d", "04:e", "05:f", "06:g", "07:h", "08:i", "09:j", "10:k"},
+			want:  "abcdefghijk",
+		}, {
+			name:  "Stray line",
+			lines: []string{"00:header", "v=spf1 -all", "01:.payload.", "02:signature"},
+			expected: []ReassemblyProblem{
+				{Kind: MalformedLine, Index: -1, Line: 1, Reason: "missing ':' separator"},
+			},
+		}, {
+			name:  "Duplicate chunk",
+			lines: []string{"00:header", "01:.payload.", "01:.other.", "02:signature"},
+			expected: []ReassemblyProblem{
+				{Kind: DuplicateChunk, Index: 1, Line: 2, Reason: "has the same index as line 1"},
+			},
+		}, {
+			name:  "Mixed index bases",
+			lines: []string{"00:header", "01:.payload.", "02:signature", "01:header", "02:.payload.", "03:signature"},
+			expected: []ReassemblyProblem{
+				{Kind: DuplicateChunk, Index: 1, Line: 3, Reason: "has the same index as line 1"},
+				{Kind: DuplicateChunk, Index: 2, Line: 4, Reason: "has the same index as line 2"},
+			},
+		}, {
+			name:  "Gap",
+			lines: []string{"00:header", "01:.payload.", "03:signature"},
+			expected: []ReassemblyProblem{
+				{Kind: MissingChunk, Index: 2, Line: -1, Reason: "no line has the index"},
+			},
+		}, {
+			name:  "Inconsistent index",
+			lines: []string{"00:header", "1:.payload.", " 02:signature", "003:"},
+			expected: []ReassemblyProblem{
+				{Kind: InconsistentIndex, Index: 1, Line: 1, Reason: `the index should be written as "01"`},
+				{Kind: InconsistentIndex, Index: 2, Line: 2, Reason: `the index should be written as "02"`},
+				{Kind: InconsistentIndex, Index: 3, Line: 3, Reason: `the index should be written as "03"`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txt, problems := reassembleLines(tt.lines, true)
+			assert.Equal(t, tt.want, txt)
+			assert.Equal(t, tt.expected, problems)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"time"
+)
+
+// RetryPolicy describes how failed TXT lookups are retried.  All attempts
+// share the deadline set by WithTimeout.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of lookups made, including the first.
+	// A value of zero or less sets the default of 3.
+	MaxAttempts int
+
+	// Backoff is the wait before the second attempt.  The wait doubles after
+	// each attempt up to MaxBackoff.  A value of zero or less sets the default
+	// of 100ms.
+	Backoff time.Duration
+
+	// MaxBackoff is the longest wait between attempts.  A value of zero or
+	// less sets the default of 2s.
+	MaxBackoff time.Duration
+
+	// Jitter is the fraction of each wait that is randomly added or removed.
+	// It must be between 0 and 1.
+	Jitter float64
+
+	// Retryable reports if the lookup should be retried after the error.  If
+	// nil, IsRetryable is used.
+	Retryable func(error) bool
+}
+
+// IsRetryable is the default classification of lookup errors.  Timeouts and
+// temporary DNS failures (such as SERVFAIL) are retried, while answers that
+// the name doesn't exist and all other errors are not.
+func IsRetryable(err error) bool {
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) {
+		return false
+	}
+
+	if dnsErr.IsNotFound {
+		return false
+	}
+
+	return dnsErr.IsTimeout || dnsErr.IsTemporary
+}
+
+// normalize applies the defaults and validates the policy.
+func (p RetryPolicy) normalize() (*RetryPolicy, error) {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = 100 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 2 * time.Second
+	}
+	p.MaxBackoff = max(p.MaxBackoff, p.Backoff)
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return nil, errors.New("jitter must be between 0 and 1")
+	}
+	if p.Retryable == nil {
+		p.Retryable = IsRetryable
+	}
+
+	return &p, nil
+}
+
+// do calls fn until it succeeds, the error isn't retryable, the attempts are
+// used up, or the context is done.
+func (p *RetryPolicy) do(ctx context.Context, fn func(context.Context) (*TXTResult, error)) (*TXTResult, error) {
+	var errs []error
+
+	for attempt := 0; ; attempt++ {
+		answer, err := fn(ctx)
+		if err == nil {
+			return answer, nil
+		}
+
+		errs = append(errs, err)
+		if attempt+1 >= p.MaxAttempts || ctx.Err() != nil || !p.Retryable(err) {
+			return nil, errors.Join(errs...)
+		}
+
+		timer := time.NewTimer(jitter(backoff(p.Backoff, p.MaxBackoff, attempt), p.Jitter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.Join(append(errs, ctx.Err())...)
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the wait after the given number of consecutive failures,
+// starting at minWait and doubling up to maxWait.
+func backoff(minWait, maxWait time.Duration, failures int) time.Duration {
+	wait := minWait
+	for i := 0; i < failures && wait < maxWait; i++ {
+		wait *= 2
+	}
+
+	return min(wait, maxWait)
+}
+
+// jitter spreads d evenly over d +/- (fraction * d).
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 || d <= 0 {
+		return d
+	}
+
+	spread := float64(d) * fraction
+	return d + time.Duration(spread*(2*rand.Float64()-1)) // nolint:gosec
+}
@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchWithRetry(t *testing.T) {
+	a, err := MakeTrustedSet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+
+	timeout := &net.DNSError{Err: "i/o timeout", IsTimeout: true}
+	nxdomain := &net.DNSError{Err: "no such host", IsNotFound: true}
+
+	tests := []struct {
+		name         string
+		policy       RetryPolicy
+		failures     int
+		failure      error
+		timeout      time.Duration
+		wantAttempts int64
+		wantErr      error
+	}{
+		{
+			name:         "succeeds after retrying timeouts",
+			policy:       RetryPolicy{Backoff: time.Millisecond},
+			failures:     2,
+			failure:      timeout,
+			wantAttempts: 3,
+		}, {
+			name:         "attempts are used up",
+			policy:       RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond},
+			failures:     5,
+			failure:      timeout,
+			wantAttempts: 2,
+			wantErr:      timeout,
+		}, {
+			name:         "not found is not retried",
+			policy:       RetryPolicy{Backoff: time.Millisecond},
+			failures:     5,
+			failure:      nxdomain,
+			wantAttempts: 1,
+			wantErr:      nxdomain,
+		}, {
+			name: "custom retryable errors",
+			policy: RetryPolicy{
+				Backoff:   time.Millisecond,
+				Jitter:    0.5,
+				Retryable: func(error) bool { return true },
+			},
+			failures:     2,
+			failure:      nxdomain,
+			wantAttempts: 3,
+		}, {
+			name: "attempts share the deadline",
+			policy: RetryPolicy{
+				MaxAttempts: 100,
+				Backoff:     50 * time.Millisecond,
+			},
+			failures:     100,
+			failure:      timeout,
+			timeout:      120 * time.Millisecond,
+			wantAttempts: 2,
+			wantErr:      context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int64
+			resolver := resolverFunc(func(ctx context.Context, name string) ([]string, error) {
+				if attempts.Add(1) <= int64(tt.failures) {
+					return nil, tt.failure
+				}
+				return a.resolver.LookupTXT(ctx, name)
+			})
+
+			fetcher, err := New(
+				WithFQDN(a.fqdn),
+				WithResolver(resolver),
+				WithParseOptions(a.provider),
+				WithRetry(tt.policy),
+				WithTimeout(tt.timeout),
+			)
+			require.NoError(t, err)
+
+			_, buf, err := fetcher.Fetch(context.Background())
+			assert.Equal(t, tt.wantAttempts, attempts.Load())
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, a.payload, buf)
+		})
+	}
+}
+
+func TestWithRetryInvalid(t *testing.T) {
+	fetcher, err := New(
+		WithFQDN("fqdn.example.org"),
+		WithRetry(RetryPolicy{Jitter: 2}),
+	)
+	assert.Nil(t, fetcher)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(&net.DNSError{IsTimeout: true}))
+	assert.True(t, IsRetryable(&net.DNSError{IsTemporary: true}))
+	assert.False(t, IsRetryable(&net.DNSError{IsNotFound: true}))
+	assert.False(t, IsRetryable(&net.DNSError{IsNotFound: true, IsTemporary: true}))
+	assert.False(t, IsRetryable(errors.New("other")))
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, backoff(time.Second, 5*time.Second, 0))
+	assert.Equal(t, 2*time.Second, backoff(time.Second, 5*time.Second, 1))
+	assert.Equal(t, 4*time.Second, backoff(time.Second, 5*time.Second, 2))
+	assert.Equal(t, 5*time.Second, backoff(time.Second, 5*time.Second, 3))
+	assert.Equal(t, 5*time.Second, backoff(time.Second, 5*time.Second, 100))
+}
+
+func TestJitter(t *testing.T) {
+	assert.Equal(t, time.Second, jitter(time.Second, 0))
+	for range 100 {
+		got := jitter(time.Second, 0.5)
+		assert.GreaterOrEqual(t, got, 500*time.Millisecond)
+		assert.LessOrEqual(t, got, 1500*time.Millisecond)
+	}
+}
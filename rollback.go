@@ -0,0 +1,227 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+// VersionStore holds the highest version of the token accepted for each FQDN,
+// so the protection against rollback survives restarts.  It must be safe for
+// concurrent use.
+type VersionStore interface {
+	// Load returns the highest version accepted for the FQDN, or false if
+	// none has been.
+	Load(ctx context.Context, fqdn string) (int64, bool, error)
+
+	// Store records the highest version accepted for the FQDN.
+	Store(ctx context.Context, fqdn string, version int64) error
+}
+
+// rollback is the protection against being served an older token than the
+// last one accepted.
+type rollback struct {
+	// claim is the name of the version claim.
+	claim string
+
+	// store holds the high-water marks.
+	store VersionStore
+
+	// mu serializes the check and update of the high-water marks.
+	mu sync.Mutex
+}
+
+// check returns an error if the version of the token is lower than the
+// highest accepted for the name, and otherwise raises the high-water mark to
+// the version of the token if raise is set.  A failure to store the new
+// high-water mark is only logged, as the token is still acceptable.
+func (rb *rollback) check(ctx context.Context, logger *slog.Logger, name string, token jwt.Token, raise bool) error {
+	version, ok := tokenVersion(token, rb.claim)
+	if !ok {
+		return fmt.Errorf("%w: the %q claim is missing or not an integer", ErrInvalidJWT, rb.claim)
+	}
+
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	highest, found, err := rb.store.Load(ctx, name)
+	if err != nil {
+		return fmt.Errorf("%w: the last accepted version can't be loaded: %w", ErrInvalidJWT, err)
+	}
+	if found && version < highest {
+		return fmt.Errorf("%w: %w: the %q claim %d is lower than %d",
+			ErrInvalidJWT, ErrRollback, rb.claim, version, highest)
+	}
+	if !raise || (found && version == highest) {
+		return nil
+	}
+
+	if err := rb.store.Store(ctx, name, version); err != nil {
+		logger.LogAttrs(ctx, slog.LevelWarn, "the version of the JWT can't be stored",
+			slog.Int64("version", version),
+			slog.Any("error", err),
+		)
+	}
+
+	return nil
+}
+
+// tokenVersion returns the version claim of the token as an integer.  Time
+// claims, like iat, are in seconds since the epoch.
+func tokenVersion(token jwt.Token, claim string) (int64, bool) {
+	value, ok := token.Get(claim)
+	if !ok {
+		return 0, false
+	}
+
+	switch v := value.(type) {
+	case time.Time:
+		return v.Unix(), !v.IsZero()
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	case json.Number:
+		n, err := v.Int64()
+		return n, err == nil
+	}
+
+	return 0, false
+}
+
+// memoryVersionStore is a VersionStore that only lasts as long as the
+// process.
+type memoryVersionStore struct {
+	mu       sync.Mutex
+	versions map[string]int64
+}
+
+func (m *memoryVersionStore) Load(_ context.Context, fqdn string) (int64, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	version, ok := m.versions[fqdn]
+	return version, ok, nil
+}
+
+func (m *memoryVersionStore) Store(_ context.Context, fqdn string, version int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.versions == nil {
+		m.versions = make(map[string]int64)
+	}
+	m.versions[fqdn] = version
+	return nil
+}
+
+// FileVersionStore is a VersionStore backed by a JSON file mapping each FQDN
+// to the highest version accepted.  The file is replaced atomically on each
+// update, so a crash never leaves it half written.  If the file can't be
+// written, the version is still kept in memory, so the protection holds for
+// the life of the process.
+type FileVersionStore struct {
+	path string
+
+	mu       sync.Mutex
+	versions map[string]int64
+}
+
+var _ VersionStore = (*FileVersionStore)(nil)
+
+// NewFileVersionStore creates a new FileVersionStore that keeps the versions
+// in the file at the path.  The file is created on the first update if it
+// doesn't exist.
+func NewFileVersionStore(path string) (*FileVersionStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("%w path must be set", ErrInvalidInput)
+	}
+
+	f := FileVersionStore{
+		path:     path,
+		versions: make(map[string]int64),
+	}
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &f, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(buf, &f.versions); err != nil {
+		return nil, fmt.Errorf("%w version file %q: %w", ErrInvalidInput, path, err)
+	}
+
+	// A file holding null leaves no map to store in.
+	if f.versions == nil {
+		f.versions = make(map[string]int64)
+	}
+
+	return &f, nil
+}
+
+// Load returns the highest version accepted for the FQDN, or false if none
+// has been.
+func (f *FileVersionStore) Load(_ context.Context, fqdn string) (int64, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	version, ok := f.versions[fqdn]
+	return version, ok, nil
+}
+
+// Store records the highest version accepted for the FQDN and writes the
+// file.  If the file can't be written, the error is returned but the version
+// is still recorded in memory.
+func (f *FileVersionStore) Store(_ context.Context, fqdn string, version int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.versions[fqdn] = version
+
+	return f.write()
+}
+
+// write replaces the file with the current versions.
+func (f *FileVersionStore) write() error {
+	buf, err := json.MarshalIndent(f.versions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), "."+filepath.Base(f.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.path)
+}
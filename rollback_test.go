@@ -0,0 +1,333 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// versions serves records signed by the same key, so the record can be
+// changed between fetches.
+type versions struct {
+	t    *testing.T
+	priv *ecdsa.PrivateKey
+
+	mu    sync.Mutex
+	lines map[string][]string
+}
+
+func newVersions(t *testing.T) *versions {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	return &versions{
+		t:     t,
+		priv:  priv,
+		lines: make(map[string][]string),
+	}
+}
+
+// set serves a token with the claims at the name.
+func (v *versions) set(name string, claims map[string]any) {
+	token := jwt.New()
+	for k, val := range claims {
+		require.NoError(v.t, token.Set(k, val))
+	}
+
+	signed, err := jwt.Sign(token, jwt.WithKey(jwa.ES256, v.priv))
+	require.NoError(v.t, err)
+
+	lines, err := CreateRecord(string(signed))
+	require.NoError(v.t, err)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.lines[name] = lines
+}
+
+func (v *versions) LookupTXT(_ context.Context, name string) ([]string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.lines[name], nil
+}
+
+func (v *versions) fetcher(store VersionStore, claim string, opts ...FetcherOption) *Fetcher {
+	defaults := []FetcherOption{
+		WithFQDNs("a.example.org"),
+		WithResolver(v),
+		WithParseOptions(jwt.WithKey(jwa.ES256, v.priv.Public())),
+		WithAntiRollback(store, claim),
+	}
+	fetcher, err := New(append(defaults, opts...)...)
+	require.NoError(v.t, err)
+
+	return fetcher
+}
+
+func TestAntiRollback(t *testing.T) {
+	type step struct {
+		name     string
+		claims   map[string]any
+		wantErr  bool
+		rollback bool
+	}
+
+	iat := func(sec int64) map[string]any {
+		return map[string]any{jwt.IssuedAtKey: time.Unix(sec, 0)}
+	}
+
+	tests := []struct {
+		name  string
+		claim string
+		steps []step
+	}{
+		{
+			name: "iat",
+			steps: []step{
+				{claims: iat(1000)},
+				{claims: iat(2000)},
+				{claims: iat(1000), wantErr: true, rollback: true},
+				{claims: iat(1999), wantErr: true, rollback: true},
+				{claims: iat(2000)},
+				{claims: map[string]any{"example": "A"}, wantErr: true},
+				{claims: iat(3000)},
+			},
+		}, {
+			name: "each name has its own version",
+			steps: []step{
+				{name: "a.example.org", claims: iat(2000)},
+				{name: "b.example.org", claims: iat(1000)},
+				{name: "b.example.org", claims: iat(500), wantErr: true, rollback: true},
+				{name: "a.example.org", claims: iat(1500), wantErr: true, rollback: true},
+			},
+		}, {
+			name:  "version claim",
+			claim: "ver",
+			steps: []step{
+				{claims: map[string]any{"ver": 3}},
+				{claims: map[string]any{"ver": 2, jwt.IssuedAtKey: time.Unix(5000, 0)}, wantErr: true, rollback: true},
+				{claims: map[string]any{"ver": 4}},
+				{claims: map[string]any{"ver": 4.5}, wantErr: true},
+				{claims: map[string]any{"ver": "5"}, wantErr: true},
+				{claims: iat(6000), wantErr: true},
+				{claims: map[string]any{"ver": 5}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := newVersions(t)
+
+			// The fetchers share the store, as each name has its own version.
+			store := &memoryVersionStore{}
+			fetchers := map[string]*Fetcher{
+				"a.example.org": v.fetcher(store, tt.claim),
+				"b.example.org": v.fetcher(store, tt.claim, WithFQDN("b.example.org")),
+			}
+
+			for i, s := range tt.steps {
+				name := s.name
+				if name == "" {
+					name = "a.example.org"
+				}
+				v.set(name, s.claims)
+
+				_, _, err := fetchers[name].Fetch(context.Background())
+				if !s.wantErr {
+					require.NoError(t, err, "step %d", i)
+					continue
+				}
+
+				require.ErrorIs(t, err, ErrInvalidJWT, "step %d", i)
+				assert.Equal(t, s.rollback, errors.Is(err, ErrRollback), "step %d", i)
+
+				var fe *FetchError
+				require.ErrorAs(t, err, &fe)
+				assert.Equal(t, StageVerify, fe.Stage)
+			}
+		})
+	}
+}
+
+func TestAntiRollbackFileStore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "versions.json")
+
+	v := newVersions(t)
+	v.set("a.example.org", map[string]any{jwt.IssuedAtKey: time.Unix(2000, 0)})
+
+	store, err := NewFileVersionStore(path)
+	require.NoError(t, err)
+
+	_, _, err = v.fetcher(store, "").Fetch(context.Background())
+	require.NoError(t, err)
+
+	buf, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var stored map[string]int64
+	require.NoError(t, json.Unmarshal(buf, &stored))
+	assert.Equal(t, map[string]int64{"a.example.org": 2000}, stored)
+
+	// After a restart, an older token is still rejected.
+	v.set("a.example.org", map[string]any{jwt.IssuedAtKey: time.Unix(1000, 0)})
+
+	store, err = NewFileVersionStore(path)
+	require.NoError(t, err)
+
+	_, _, err = v.fetcher(store, "").Fetch(context.Background())
+	assert.ErrorIs(t, err, ErrRollback)
+
+	// Only the version file is left in the directory.
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestAntiRollbackStoreFailure(t *testing.T) {
+	// The directory of the file doesn't exist, so storing fails.
+	store, err := NewFileVersionStore(filepath.Join(t.TempDir(), "missing", "versions.json"))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	v := newVersions(t)
+	v.set("a.example.org", map[string]any{jwt.IssuedAtKey: time.Unix(2000, 0)})
+
+	// The token is still accepted, and the failure is logged.
+	_, _, err = v.fetcher(store, "", WithLogger(newTestLogger(&buf))).Fetch(context.Background())
+	require.NoError(t, err)
+
+	var warned bool
+	for _, entry := range logEntries(t, &buf) {
+		if entry["level"] == "WARN" {
+			warned = true
+			assert.Equal(t, "the version of the JWT can't be stored", entry["msg"])
+			assert.Equal(t, "a.example.org", entry["fqdn"])
+		}
+	}
+	assert.True(t, warned)
+
+	// The version is still kept in memory, so an older token is rejected.
+	version, found, err := store.Load(context.Background(), "a.example.org")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, int64(2000), version)
+
+	v.set("a.example.org", map[string]any{jwt.IssuedAtKey: time.Unix(1000, 0)})
+	_, _, err = v.fetcher(store, "").Fetch(context.Background())
+	assert.ErrorIs(t, err, ErrRollback)
+}
+
+func TestAntiRollbackDiagnose(t *testing.T) {
+	store := &memoryVersionStore{}
+	v := newVersions(t)
+	fetcher := v.fetcher(store, "")
+
+	v.set("a.example.org", map[string]any{jwt.IssuedAtKey: time.Unix(2000, 0)})
+	report, err := fetcher.Diagnose(context.Background())
+	require.NoError(t, err)
+	assert.True(t, report.Names[0].Verified)
+
+	// The diagnosis didn't raise the highest version accepted.
+	_, found, err := store.Load(context.Background(), "a.example.org")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	v.set("a.example.org", map[string]any{jwt.IssuedAtKey: time.Unix(1000, 0)})
+	_, _, err = fetcher.Fetch(context.Background())
+	require.NoError(t, err)
+
+	// The diagnosis still reports a rollback.
+	v.set("a.example.org", map[string]any{jwt.IssuedAtKey: time.Unix(500, 0)})
+	report, err = fetcher.Diagnose(context.Background())
+	require.NoError(t, err)
+	assert.False(t, report.Names[0].Verified)
+	assert.Contains(t, report.Names[0].Error, ErrRollback.Error())
+}
+
+func TestAntiRollbackRacingResolvers(t *testing.T) {
+	v := newVersions(t)
+
+	v.set("a.example.org", map[string]any{jwt.IssuedAtKey: time.Unix(1000, 0)})
+	old, err := v.LookupTXT(context.Background(), "a.example.org")
+	require.NoError(t, err)
+	v.set("a.example.org", map[string]any{jwt.IssuedAtKey: time.Unix(3000, 0)})
+	current, err := v.LookupTXT(context.Background(), "a.example.org")
+	require.NoError(t, err)
+
+	// The fast resolver lags behind and serves an older token.
+	fast := resolverFunc(func(context.Context, string) ([]string, error) {
+		return old, nil
+	})
+	slow := resolverFunc(func(context.Context, string) ([]string, error) {
+		time.Sleep(20 * time.Millisecond)
+		return current, nil
+	})
+
+	store := &memoryVersionStore{}
+	require.NoError(t, store.Store(context.Background(), "a.example.org", 2000))
+
+	fetcher := v.fetcher(store, "", WithRacingResolvers(time.Millisecond, fast, slow))
+
+	token, _, err := fetcher.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3000), token.IssuedAt().Unix())
+
+	version, _, err := store.Load(context.Background(), "a.example.org")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3000), version)
+}
+
+func TestNewFileVersionStoreInvalid(t *testing.T) {
+	_, err := NewFileVersionStore("")
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	path := filepath.Join(t.TempDir(), "versions.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+
+	_, err = NewFileVersionStore(path)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	_, err = NewFileVersionStore(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestNewFileVersionStoreNull(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "versions.json")
+	require.NoError(t, os.WriteFile(path, []byte("null"), 0o600))
+
+	store, err := NewFileVersionStore(path)
+	require.NoError(t, err)
+
+	_, found, err := store.Load(context.Background(), "a.example.org")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, store.Store(context.Background(), "a.example.org", 1000))
+
+	store, err = NewFileVersionStore(path)
+	require.NoError(t, err)
+
+	version, found, err := store.Load(context.Background(), "a.example.org")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, int64(1000), version)
+}
@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchServeStale(t *testing.T) {
+	now := time.Now()
+
+	a, err := MakeTrustedSet("fqdn.example.org", map[string]any{
+		"example": "A",
+		"exp":     now.Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	b, err := MakeTrustedSet("fqdn.example.org", map[string]any{"example": "B"})
+	require.NoError(t, err)
+
+	servfail := &net.DNSError{Err: "server misbehaving", IsTemporary: true}
+
+	tests := []struct {
+		name      string
+		options   []FetcherOption
+		advance   time.Duration
+		ttl       time.Duration
+		failure   Resolver
+		wantStale bool
+	}{
+		{
+			name:      "serves the stale token",
+			options:   []FetcherOption{WithServeStale(time.Minute)},
+			failure:   failingResolver(servfail),
+			wantStale: true,
+		}, {
+			name:      "serves the stale token until it expires",
+			options:   []FetcherOption{WithServeStale(0)},
+			advance:   30 * time.Minute,
+			failure:   failingResolver(servfail),
+			wantStale: true,
+		}, {
+			name:      "serves the stale token after the TTL",
+			options:   []FetcherOption{WithServeStale(30 * time.Minute)},
+			advance:   2 * time.Minute,
+			ttl:       time.Minute,
+			failure:   failingResolver(servfail),
+			wantStale: true,
+		}, {
+			name:      "serves the stale token after the TTL with a cache",
+			options:   []FetcherOption{WithServeStale(30 * time.Minute), WithCache(0)},
+			advance:   2 * time.Minute,
+			ttl:       time.Minute,
+			failure:   failingResolver(servfail),
+			wantStale: true,
+		}, {
+			name:    "outside the stale window",
+			options: []FetcherOption{WithServeStale(time.Minute)},
+			advance: 2 * time.Minute,
+			failure: failingResolver(servfail),
+		}, {
+			name:    "the token expired",
+			options: []FetcherOption{WithServeStale(2 * time.Hour)},
+			advance: 90 * time.Minute,
+			failure: failingResolver(servfail),
+		}, {
+			name:    "verification failures are not masked",
+			options: []FetcherOption{WithServeStale(time.Minute)},
+			failure: b.resolver,
+		}, {
+			name:    "serving stale is disabled",
+			failure: failingResolver(servfail),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var failing atomic.Bool
+			resolver := resolverFunc(func(ctx context.Context, name string) ([]string, error) {
+				if failing.Load() {
+					return tt.failure.LookupTXT(ctx, name)
+				}
+				return a.resolver.LookupTXT(ctx, name)
+			})
+
+			var r Resolver = resolver
+			if tt.ttl > 0 {
+				r = ttlResolver{Resolver: resolver, ttl: tt.ttl}
+			}
+
+			opts := append([]FetcherOption{
+				WithFQDN(a.fqdn),
+				WithResolver(r),
+				WithParseOptions(a.provider),
+			}, tt.options...)
+
+			fetcher, err := New(opts...)
+			require.NoError(t, err)
+
+			clock := now
+			fetcher.clock = jwt.ClockFunc(func() time.Time { return clock })
+
+			ctx := context.Background()
+			_, _, err = fetcher.Fetch(ctx)
+			require.NoError(t, err)
+
+			failing.Store(true)
+			clock = clock.Add(tt.advance)
+
+			token, buf, err := fetcher.Fetch(ctx)
+			require.Error(t, err)
+
+			if !tt.wantStale {
+				assert.Nil(t, token)
+				assert.Nil(t, buf)
+				assert.NotErrorIs(t, err, ErrStale)
+				return
+			}
+
+			assert.ErrorIs(t, err, ErrStale)
+			assert.ErrorIs(t, err, servfail)
+			require.NotNil(t, token)
+			assert.Equal(t, a.payload, buf)
+		})
+	}
+}
+
+// ttlResolver is a TXTRecordResolver that answers with the TTL.
+type ttlResolver struct {
+	Resolver
+	ttl time.Duration
+}
+
+func (r ttlResolver) LookupTXTRecord(ctx context.Context, name string) (*TXTResult, error) {
+	lines, err := r.LookupTXT(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TXTResult{Lines: lines, TTL: r.ttl}, nil
+}
+
+func failingResolver(err error) Resolver {
+	return resolverFunc(func(context.Context, string) ([]string, error) {
+		return nil, err
+	})
+}
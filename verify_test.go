@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyLines(t *testing.T) {
+	a, err := MakePublicKeySet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+	other, err := MakePublicKeySet("fqdn.example.org", map[string]any{"example": "B"})
+	require.NoError(t, err)
+	expired, err := MakePublicKeySet("fqdn.example.org", map[string]any{"exp": time.Now().Add(-time.Hour)})
+	require.NoError(t, err)
+
+	record, err := CreateRecord(string(a.jwt), WithMaxLineLength(50))
+	require.NoError(t, err)
+	expiredRecord, err := CreateRecord(string(expired.jwt))
+	require.NoError(t, err)
+
+	require.Greater(t, len(record), 2)
+	duplicated := append([]string{record[0]}, record...)
+	missing := append([]string{record[0]}, record[2:]...)
+
+	tests := []struct {
+		name      string
+		lines     []string
+		provider  Set
+		opts      []FetcherOption
+		want      []byte
+		wantStage Stage
+		wantErrs  []error
+	}{
+		{
+			name:     "valid",
+			lines:    record,
+			provider: a,
+			want:     a.payload,
+		}, {
+			name:     "duplicated line",
+			lines:    duplicated,
+			provider: a,
+			want:     a.payload,
+		}, {
+			name:      "duplicated line, strict",
+			lines:     duplicated,
+			provider:  a,
+			opts:      []FetcherOption{WithStrictReassembly()},
+			wantStage: StageReassemble,
+			wantErrs:  []error{ErrDuplicateChunk, ErrInvalidJWT},
+		}, {
+			name:      "missing chunk",
+			lines:     missing,
+			provider:  a,
+			wantStage: StageReassemble,
+			wantErrs:  []error{ErrMissingChunk, ErrInvalidJWT},
+		}, {
+			name:      "bad signature",
+			lines:     record,
+			provider:  other,
+			wantStage: StageVerify,
+			wantErrs:  []error{ErrBadSignature, ErrInvalidJWT},
+		}, {
+			name:      "expired",
+			lines:     expiredRecord,
+			provider:  expired,
+			wantStage: StageVerify,
+			wantErrs:  []error{ErrExpired, ErrInvalidJWT},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []FetcherOption{
+				WithFQDN(a.fqdn),
+				WithResolver(resolverFunc(func(context.Context, string) ([]string, error) {
+					t.Error("no lookup should be made")
+					return nil, nil
+				})),
+				WithParseOptions(tt.provider.provider),
+			}
+			fetcher, err := New(append(opts, tt.opts...)...)
+			require.NoError(t, err)
+
+			token, buf, err := fetcher.VerifyLines(context.Background(), tt.lines)
+			if tt.wantErrs == nil {
+				require.NoError(t, err)
+				require.NotNil(t, token)
+				assert.Equal(t, tt.want, buf)
+				return
+			}
+
+			assert.Nil(t, token)
+			assert.Nil(t, buf)
+
+			var fe *FetchError
+			require.ErrorAs(t, err, &fe)
+			assert.Empty(t, fe.FQDN)
+			assert.Equal(t, tt.wantStage, fe.Stage)
+			for _, want := range tt.wantErrs {
+				assert.ErrorIs(t, err, want)
+			}
+		})
+	}
+}
+
+func TestVerifyToken(t *testing.T) {
+	a, err := MakePublicKeySet("fqdn.example.org", map[string]any{"example": "A"})
+	require.NoError(t, err)
+
+	var rec recorder
+	fetcher, err := New(
+		WithFQDN(a.fqdn),
+		WithResolver(a.resolver),
+		WithParseOptions(a.provider, jwt.WithRequiredClaim("example")),
+		WithCache(time.Hour),
+		WithObserver(&rec),
+	)
+	require.NoError(t, err)
+
+	// The result is the same as Fetch.
+	fetched, payload, err := fetcher.Fetch(context.Background())
+	require.NoError(t, err)
+
+	token, buf, err := fetcher.VerifyToken(context.Background(), string(a.jwt))
+	require.NoError(t, err)
+	assert.Equal(t, payload, buf)
+	assert.Equal(t, fetched.PrivateClaims(), token.PrivateClaims())
+
+	// The verification is recorded without the name.
+	verify := rec.events[len(rec.events)-1].(VerifyEvent)
+	assert.Empty(t, verify.FQDN)
+	assert.NoError(t, verify.Err)
+
+	// The parse options are applied.
+	b, err := MakePublicKeySet("fqdn.example.org", map[string]any{"other": "B"})
+	require.NoError(t, err)
+
+	_, _, err = fetcher.VerifyToken(context.Background(), string(b.jwt))
+	assert.ErrorIs(t, err, ErrInvalidJWT)
+	assert.True(t, strings.HasPrefix(err.Error(), "verify: "), err.Error())
+
+	_, _, err = fetcher.VerifyToken(context.Background(), "")
+	assert.ErrorIs(t, err, ErrInvalidJWT)
+
+	// The cache isn't affected by a failed verification.
+	_, cached, err := fetcher.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, payload, cached)
+}
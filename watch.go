@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+// Event is delivered by a Watcher when the verified token changes.
+type Event struct {
+	// Previous is the token that was replaced.  It is nil for the first
+	// token found by the Watcher.
+	Previous jwt.Token
+
+	// Current is the newly verified token.
+	Current jwt.Token
+
+	// Payload is the payload of the current token as bytes.
+	Payload []byte
+}
+
+// Watcher refreshes the token in the background and reports when it changes.
+type Watcher struct {
+	fetcher  *Fetcher
+	interval time.Duration
+	jitter   float64
+	minWait  time.Duration
+	maxWait  time.Duration
+	callback func(Event)
+
+	events chan Event
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// WatchOption is the interface that all Watch options must implement.
+type WatchOption interface {
+	apply(*Watcher) error
+}
+
+// Watch starts refreshing the token in the background.  The first refresh is
+// performed immediately, and an Event is only delivered when the verified
+// token differs from the last one seen.  Events are delivered on the channel
+// returned by Events unless a callback is set using WithWatchFunc.  The
+// Watcher stops when the context is canceled or Close is called.
+func (r *Fetcher) Watch(ctx context.Context, opts ...WatchOption) (*Watcher, error) {
+	if len(r.fqdns) == 0 {
+		return nil, fmt.Errorf("%w fqdn must be set", ErrInvalidInput)
+	}
+
+	w := Watcher{
+		fetcher: r,
+		events:  make(chan Event),
+		done:    make(chan struct{}),
+	}
+
+	defaults := []WatchOption{ // nolint:prealloc
+		WithWatchInterval(0),
+		WithWatchJitter(-1),
+		WithWatchBackoff(0, 0),
+	}
+
+	opts = append(defaults, opts...)
+
+	for _, opt := range opts {
+		if opt != nil {
+			if err := opt.apply(&w); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if w.maxWait <= 0 {
+		w.maxWait = w.interval
+	}
+	w.maxWait = max(w.maxWait, w.minWait)
+
+	ctx, w.cancel = context.WithCancel(ctx)
+
+	go w.run(ctx)
+
+	return &w, nil
+}
+
+// Events returns the channel that changes are delivered on.  The channel is
+// closed when the Watcher stops.
+func (w *Watcher) Events() <-chan Event {
+	return w.events
+}
+
+// Close stops the Watcher and waits for the background goroutine to exit.
+func (w *Watcher) Close() error {
+	w.cancel()
+	<-w.done
+	return nil
+}
+
+func (w *Watcher) run(ctx context.Context) {
+	defer close(w.done)
+	defer close(w.events)
+
+	var (
+		last     *result
+		failures int
+	)
+
+	for {
+		var wait time.Duration
+
+		res, err := w.fetcher.load(ctx, w.fetcher.fqdns)
+		switch {
+		case err != nil:
+			wait = backoff(w.minWait, w.maxWait, failures)
+			failures++
+		default:
+			failures = 0
+			wait = w.interval
+			if last == nil || last.raw != res.raw {
+				var prev jwt.Token
+				if last != nil {
+					prev = last.token
+				}
+				if !w.deliver(ctx, Event{Previous: prev, Current: res.token, Payload: res.payload}) {
+					return
+				}
+				last = res
+			}
+		}
+
+		timer := time.NewTimer(jitter(wait, w.jitter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// deliver sends the event to the callback or the channel.  It returns false
+// if the Watcher was stopped before the event could be delivered.
+func (w *Watcher) deliver(ctx context.Context, e Event) bool {
+	if w.callback != nil {
+		w.callback(e)
+		return true
+	}
+
+	select {
+	case w.events <- e:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"fmt"
+	"time"
+)
+
+type watchOptionFunc func(*Watcher) error
+
+func (f watchOptionFunc) apply(w *Watcher) error {
+	return f(w)
+}
+
+// WithWatchInterval sets how often the token is refreshed after a successful
+// refresh.  Any value of zero or less sets the default of 5m.
+func WithWatchInterval(interval time.Duration) WatchOption {
+	return watchOptionFunc(
+		func(w *Watcher) error {
+			if interval <= 0 {
+				interval = 5 * time.Minute
+			}
+			w.interval = interval
+			return nil
+		},
+	)
+}
+
+// WithWatchJitter sets the fraction of each wait that is randomly added or
+// removed so a fleet of watchers doesn't refresh in lock step.  A value of 0
+// disables the jitter, and any value less than zero sets the default of 0.1.
+// Values greater than 1 are invalid.
+func WithWatchJitter(jitter float64) WatchOption {
+	return watchOptionFunc(
+		func(w *Watcher) error {
+			if jitter < 0 {
+				jitter = 0.1
+			}
+			if jitter > 1 {
+				return fmt.Errorf("%w jitter must be between 0 and 1", ErrInvalidInput)
+			}
+			w.jitter = jitter
+			return nil
+		},
+	)
+}
+
+// WithWatchBackoff sets the wait after a failed refresh.  The wait starts at
+// minWait and doubles after each consecutive failure up to maxWait.  A minWait
+// of zero or less sets the default of 1s, and a maxWait of zero or less sets
+// the default of the refresh interval.
+func WithWatchBackoff(minWait, maxWait time.Duration) WatchOption {
+	return watchOptionFunc(
+		func(w *Watcher) error {
+			if minWait <= 0 {
+				minWait = time.Second
+			}
+			w.minWait = minWait
+			w.maxWait = maxWait
+			return nil
+		},
+	)
+}
+
+// WithWatchFunc sets a callback that receives each Event instead of the
+// channel returned by Events.  The callback is called from the Watcher's
+// goroutine, so it should not block.
+func WithWatchFunc(fn func(Event)) WatchOption {
+	return watchOptionFunc(
+		func(w *Watcher) error {
+			w.callback = fn
+			return nil
+		},
+	)
+}
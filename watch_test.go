@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: 2025 Comcast Cable Communications Management, LLC
+// SPDX-License-Identifier: Apache-2.0
+
+package dnstxtjwt
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/jwskeychain"
+	"github.com/xmidt-org/jwskeychain/keychaintest"
+)
+
+// rotatingRecords creates records signed by the same chain so the record
+// served can be swapped while the same parse options still verify it.
+func rotatingRecords(t *testing.T, claims ...map[string]any) ([][]string, jwt.ParseOption) {
+	t.Helper()
+
+	chain, err := keychaintest.New(keychaintest.Desc("leaf<-ica<-root"))
+	require.NoError(t, err)
+
+	provider, err := jwskeychain.New(jwskeychain.TrustedRoots(chain.Root().Public))
+	require.NoError(t, err)
+
+	records := make([][]string, 0, len(claims))
+	for _, c := range claims {
+		JWT, err := CreateSignedJWT(chain, c)
+		require.NoError(t, err)
+
+		record, err := CreateRecord(string(JWT))
+		require.NoError(t, err)
+
+		records = append(records, record)
+	}
+
+	return records, jwt.WithKeyProvider(provider)
+}
+
+func TestWatch(t *testing.T) {
+	records, provider := rotatingRecords(t,
+		map[string]any{"example": "A"},
+		map[string]any{"example": "B"},
+	)
+
+	var current atomic.Int32
+	var failures atomic.Int32
+	resolver := resolverFunc(func(_ context.Context, _ string) ([]string, error) {
+		if failures.Load() > 0 {
+			failures.Add(-1)
+			return nil, errors.New("resolver error")
+		}
+		return records[current.Load()], nil
+	})
+
+	fetcher, err := New(
+		WithFQDN("fqdn.example.org"),
+		WithResolver(resolver),
+		WithParseOptions(provider),
+	)
+	require.NoError(t, err)
+
+	failures.Store(2)
+	w, err := fetcher.Watch(context.Background(),
+		WithWatchInterval(10*time.Millisecond),
+		WithWatchJitter(0),
+		WithWatchBackoff(time.Millisecond, 5*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	// The first token is delivered once the failures are exhausted.
+	var first Event
+	select {
+	case first = <-w.Events():
+	case <-time.After(time.Second):
+		require.FailNow(t, "timed out waiting for the first event")
+	}
+	assert.Nil(t, first.Previous)
+	require.NotNil(t, first.Current)
+	got, _ := first.Current.Get("example")
+	assert.Equal(t, "A", got)
+
+	// Nothing changed, so nothing is delivered.
+	select {
+	case e := <-w.Events():
+		require.FailNow(t, "unexpected event", "%v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	current.Store(1)
+
+	var second Event
+	select {
+	case second = <-w.Events():
+	case <-time.After(time.Second):
+		require.FailNow(t, "timed out waiting for the second event")
+	}
+	require.NotNil(t, second.Previous)
+	prev, _ := second.Previous.Get("example")
+	got, _ = second.Current.Get("example")
+	assert.Equal(t, "A", prev)
+	assert.Equal(t, "B", got)
+	assert.NotEmpty(t, second.Payload)
+
+	require.NoError(t, w.Close())
+
+	_, open := <-w.Events()
+	assert.False(t, open)
+}
+
+func TestWatchCallbackAndCancel(t *testing.T) {
+	records, provider := rotatingRecords(t, map[string]any{"example": "A"})
+
+	fetcher, err := New(
+		WithFQDN("fqdn.example.org"),
+		WithResolver(resolverFunc(func(_ context.Context, _ string) ([]string, error) {
+			return records[0], nil
+		})),
+		WithParseOptions(provider),
+	)
+	require.NoError(t, err)
+
+	events := make(chan Event, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	w, err := fetcher.Watch(ctx,
+		WithWatchInterval(time.Millisecond),
+		WithWatchFunc(func(e Event) {
+			events <- e
+		}),
+	)
+	require.NoError(t, err)
+
+	select {
+	case e := <-events:
+		assert.NotNil(t, e.Current)
+	case <-time.After(time.Second):
+		require.FailNow(t, "timed out waiting for the event")
+	}
+
+	cancel()
+
+	select {
+	case <-w.done:
+	case <-time.After(time.Second):
+		require.FailNow(t, "watcher did not stop")
+	}
+
+	assert.Len(t, events, 0)
+	assert.NoError(t, w.Close())
+}
+
+func TestWatchInvalidOptions(t *testing.T) {
+	fetcher, err := New(WithFQDN("fqdn.example.org"))
+	require.NoError(t, err)
+
+	w, err := fetcher.Watch(context.Background(), WithWatchJitter(2))
+	assert.Nil(t, w)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}